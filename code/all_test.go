@@ -3,6 +3,8 @@ package code
 import (
 	"go/types"
 	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -64,6 +66,532 @@ func TestID(t *testing.T) {
 	check2(mathPkg, "Int", "math.Int")
 }
 
+func TestSplitExampleName(t *testing.T) {
+	var cases = []struct {
+		name, exemplified, suffix string
+	}{
+		{"", "", ""},                // Example
+		{"_basic", "", "basic"},     // Example_basic
+		{"T", "T", ""},              // ExampleT
+		{"T_M", "T_M", ""},          // ExampleT_M
+		{"F_suffix", "F", "suffix"}, // ExampleF_suffix
+		{"T_M_suffix", "T_M", "suffix"},
+		{"T_mUpper", "T", "mUpper"},
+		{"T_M_Upper", "T_M_Upper", ""},
+	}
+	for _, c := range cases {
+		exemplified, suffix := splitExampleName(c.name)
+		if exemplified != c.exemplified || suffix != c.suffix {
+			t.Errorf("splitExampleName(%q): got (%q, %q), want (%q, %q)", c.name, exemplified, suffix, c.exemplified, c.suffix)
+		}
+	}
+}
+
+func TestCollectExamples(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, "bytes")
+	analyzer.AnalyzePackages(nil)
+	pkg := analyzer.PackageByPath("bytes")
+
+	var examplesOf = func(name string) []*Example {
+		for _, tn := range pkg.AllTypeNames {
+			if tn.Name() == name {
+				return tn.Examples
+			}
+		}
+		for _, f := range pkg.AllFunctions {
+			if f.IsMethod() {
+				if _, tn, _ := f.ReceiverTypeName(); tn.Name()+"_"+f.Name() == name {
+					return f.Examples
+				}
+			} else if f.Name() == name {
+				return f.Examples
+			}
+		}
+		t.Fatalf("%s is not found", name)
+		return nil
+	}
+	var check = func(name string, suffixes ...string) {
+		examples := examplesOf(name)
+		if len(examples) != len(suffixes) {
+			t.Errorf("%s: %d examples found, want %d", name, len(examples), len(suffixes))
+			return
+		}
+		for i, ex := range examples {
+			if ex.Exemplified != name || ex.Suffix() != suffixes[i] {
+				t.Errorf("%s: example %s (suffix %q) is attached, want suffix %q", name, ex.Name, ex.Suffix(), suffixes[i])
+			}
+		}
+	}
+
+	check("Buffer", "", "reader")
+	check("Buffer_Grow", "")
+	check("Compare", "", "search")
+	if len(pkg.Examples) != 0 {
+		t.Errorf("bytes should have no package-level examples, got %d", len(pkg.Examples))
+	}
+}
+
+func TestParsePlatforms(t *testing.T) {
+	var cases = []struct {
+		list      string
+		platforms []Platform
+		ok        bool
+	}{
+		{"", nil, true},
+		{"linux/amd64", []Platform{{"linux", "amd64"}}, true},
+		{"linux/amd64, windows/arm64,", []Platform{{"linux", "amd64"}, {"windows", "arm64"}}, true},
+		{"linux", nil, false},
+		{"/amd64", nil, false},
+		{"linux/", nil, false},
+		{"linux/amd64,linux/amd64", nil, false},
+	}
+	for _, c := range cases {
+		platforms, err := ParsePlatforms(c.list)
+		if (err == nil) != c.ok {
+			t.Errorf("ParsePlatforms(%q): unexpected error: %v", c.list, err)
+			continue
+		}
+		if !reflect.DeepEqual(platforms, c.platforms) {
+			t.Errorf("ParsePlatforms(%q): %v vs. %v", c.list, platforms, c.platforms)
+		}
+	}
+}
+
+func TestMergeTestPackages(t *testing.T) {
+	const testsPath = "go101.org/golds/internal/testing/html-checking/tests"
+	var analyzer CodeAnalyzer
+	if !analyzer.ParsePackagesWithOptions(nil, ParseOptions{Tests: true}, testsPath, testsPath+"/user") {
+		t.Fatal("failed to parse packages")
+	}
+	analyzer.AnalyzePackages(nil)
+
+	pkg := analyzer.PackageByPath(testsPath)
+	var testOnlys = make(map[string]bool)
+	for _, tn := range pkg.AllTypeNames {
+		testOnlys[tn.Name()] = tn.TestOnly()
+	}
+	for name, testOnly := range map[string]bool{"Sizer": false, "T": false, "U": true, "fakeSizer": true} {
+		if only, ok := testOnlys[name]; !ok {
+			t.Errorf("type %s is not found", name)
+		} else if only != testOnly {
+			t.Errorf("type %s: test-only should be %v", name, testOnly)
+		}
+	}
+
+	scope := pkg.PPkg.Types.Scope()
+	T, sizer := scope.Lookup("T").Type(), scope.Lookup("Sizer").Type().Underlying().(*types.Interface)
+	if !types.Implements(types.NewPointer(T), sizer) {
+		t.Errorf("*T should implement Sizer with the method declared in the test file")
+	}
+
+	userPkg := analyzer.PackageByPath(testsPath + "/user")
+	w := userPkg.PPkg.Types.Scope().Lookup("W").Type().Underlying().(*types.Struct)
+	if w.Field(0).Type() != T {
+		t.Errorf("packages depending on a package with test files should use the merged one")
+	}
+
+	if extPkg := analyzer.PackageByPath(testsPath + "_test"); extPkg == nil || !extPkg.IsExternalTest() {
+		t.Errorf("the external test package is not loaded")
+	}
+}
+
+func TestGenerics(t *testing.T) {
+	const genericsPath = "go101.org/golds/internal/testing/html-checking/generics"
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, genericsPath)
+	analyzer.AnalyzePackages(nil)
+	scope := analyzer.PackageByPath(genericsPath).PPkg.Types.Scope()
+
+	intList := analyzer.RegisterType(scope.Lookup("IntList").Type())
+	var push *Method
+	for _, sel := range intList.AllMethods {
+		if sel.Method != nil && sel.Name() == "Push" {
+			push = sel.Method
+		}
+	}
+	if push == nil {
+		t.Fatalf("method Push of IntList is not found")
+	}
+	params := push.Type.TT.(*types.Signature).Params()
+	if !push.Instantiated || params.Len() != 1 || params.At(0).Type() != types.Typ[types.Int] {
+		t.Errorf("the parameter type of method Push of IntList should be int")
+	}
+
+	pusher := analyzer.RegisterType(scope.Lookup("Pusher").Type())
+	var implementedBys = make(map[string]bool)
+	for _, impBy := range pusher.ImplementedBys {
+		implementedBys[impBy.TT.String()] = true
+	}
+	for _, name := range []string{genericsPath + ".Stack", "*" + genericsPath + ".List[int]"} {
+		if !implementedBys[name] {
+			t.Errorf("%s should implement Pusher", name)
+		}
+	}
+	if implementedBys[genericsPath+".Lener"] {
+		t.Errorf("Lener should not implement Pusher")
+	}
+}
+
+func TestMergePlatformDeclarations(t *testing.T) {
+	var analyzer CodeAnalyzer
+	var linux, windows = Platform{"linux", "amd64"}, Platform{"windows", "amd64"}
+	analyzer.ParsePackagesWithOptions(nil, ParseOptions{Platforms: []Platform{linux, windows}}, "os")
+	osPkg := analyzer.PackageByPath("os")
+
+	if platforms := analyzer.PlatformsOfSourceFile(osPkg, "file.go"); platforms != nil {
+		t.Errorf("file.go should be used on all platforms, but only on %v", platforms)
+	}
+	if platforms := analyzer.PlatformsOfSourceFile(osPkg, "file_unix.go"); !reflect.DeepEqual(platforms, []Platform{linux}) {
+		t.Errorf("file_unix.go should be only used on linux, but on %v", platforms)
+	}
+
+	var found bool
+	var argvFunc *PlatformDeclaration
+	for _, decl := range analyzer.DeclarationsOnOtherPlatforms(osPkg) {
+		if decl.Kind == "file" && decl.Name == "file_windows.go" {
+			found = reflect.DeepEqual(decl.Platforms, []Platform{windows})
+		}
+		if decl.Kind == "func" && decl.Name == "commandLineToArgv" {
+			decl := decl
+			argvFunc = &decl
+		}
+	}
+	if !found {
+		t.Errorf("file_windows.go is not found as a file only on windows")
+	}
+	if argvFunc == nil {
+		t.Errorf("commandLineToArgv is not found as a function only on windows")
+	} else if argvFunc.Source != "func commandLineToArgv(cmd string) []string" || !strings.HasPrefix(argvFunc.Doc, "commandLineToArgv splits") {
+		t.Errorf("the source or doc of commandLineToArgv is not recorded: %q, %q", argvFunc.Source, argvFunc.Doc)
+	}
+}
+
+func TestExportedObjectUses(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, "strings")
+	analyzer.AnalyzePackages(nil)
+	utf8Pkg := analyzer.PackageByPath("unicode/utf8")
+	stringsPkg := analyzer.PackageByPath("strings")
+
+	var names = map[string]int{}
+	for _, ou := range analyzer.ExportedObjectUses(utf8Pkg)[stringsPkg] {
+		if !ou.Object.Exported() || ou.Object.Pkg() != utf8Pkg.PPkg.Types {
+			t.Errorf("%s should not be listed", ou.Name)
+		}
+		names[ou.Name] = len(ou.Identifiers)
+	}
+	for _, name := range []string{"RuneError", "RuneSelf", "DecodeRuneInString"} {
+		if names[name] == 0 {
+			t.Errorf("uses of utf8.%s in strings are not found", name)
+		}
+	}
+	for _, c := range utf8Pkg.AllConstants {
+		if c.Name() == "RuneError" {
+			if stat := analyzer.ResourceUseStat(c); stat.Packages == 0 || stat.Uses < names["RuneError"] {
+				t.Errorf("use stat of utf8.RuneError is not correct: %v", stat)
+			}
+		}
+	}
+	for _, res := range analyzer.UnusedExportedResources(utf8Pkg) {
+		if res.Name() == "RuneError" || analyzer.ResourceUseStat(res).Packages > 0 {
+			t.Errorf("utf8.%s should not be reported as unused", res.Name())
+		}
+	}
+	if uses := analyzer.ExportedObjectUses(stringsPkg)[stringsPkg]; uses != nil {
+		t.Errorf("uses in the declaring package should be ignored")
+	}
+
+	// Package-level variables of unnamed struct types, such as cpu.X86,
+	// are used as fake type names of their fields.
+	var numFieldUses int
+	for _, objUses := range analyzer.ExportedObjectUses(analyzer.PackageByPath("internal/cpu")) {
+		for _, ou := range objUses {
+			if v, ok := ou.Object.(*types.Var); ok && v.IsField() {
+				numFieldUses++
+				if strings.IndexByte(ou.Name, '.') < 0 {
+					t.Errorf("the owner of field cpu.%s is not found", ou.Name)
+				}
+			}
+		}
+	}
+	if numFieldUses == 0 {
+		t.Errorf("uses of the fields of internal/cpu variables are not found")
+	}
+
+	for _, objUses := range analyzer.ExportedObjectUses(analyzer.PackageByPath("sync")) {
+		for _, ou := range objUses {
+			if ou.Name == "Mutex.Lock" {
+				return
+			}
+		}
+	}
+	t.Errorf("uses of sync.Mutex.Lock are not found")
+}
+
+func TestFunctionCalls(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, "strings")
+	analyzer.AnalyzePackages(nil)
+	ioPkg := analyzer.PackageByPath("io").PPkg.Types
+	stringsPkg := analyzer.PackageByPath("strings").PPkg.Types
+
+	readAll := ioPkg.Scope().Lookup("ReadAll").(*types.Func)
+	reader := stringsPkg.Scope().Lookup("Reader").Type()
+	obj, _, _ := types.LookupFieldOrMethod(reader, true, stringsPkg, "Read")
+	readerRead := obj.(*types.Func)
+	if name := FunctionPathName(readerRead); name != "Reader.Read" {
+		t.Errorf("FunctionPathName(strings.Reader.Read) = %s", name)
+	}
+
+	var viaInterface bool
+	var lastPkg, lastName string
+	for _, call := range analyzer.FunctionCallees(readAll) {
+		if call.Via == nil {
+			continue
+		}
+		// io.nopCloser embeds io.Reader, whose abstract Read method is not a callee.
+		if types.IsInterface(call.Callee.Type().(*types.Signature).Recv().Type()) {
+			t.Errorf("the interface method %s is listed as a callee through %s", call.Callee.FullName(), call.Via.FullName())
+		}
+		if call.Callee == readerRead && call.Via.Name() == "Read" {
+			viaInterface = true
+		}
+		pkg, name := call.Callee.Pkg().Path(), FunctionPathName(call.Callee)
+		if pkg < lastPkg || pkg == lastPkg && name < lastName {
+			t.Errorf("the implementations of io.Reader.Read are not sorted: %s.%s is after %s.%s", pkg, name, lastPkg, lastName)
+		}
+		lastPkg, lastName = pkg, name
+	}
+	if !viaInterface {
+		t.Errorf("the call from io.ReadAll to strings.Reader.Read through io.Reader.Read is not found")
+	}
+
+	for _, call := range analyzer.FunctionCallers(stringsPkg.Scope().Lookup("Index").(*types.Func)) {
+		if call.Caller.Name() == "Contains" && call.Via == nil {
+			return
+		}
+	}
+	t.Errorf("the call from strings.Contains to strings.Index is not found")
+}
+
+func TestInterfaceValueMightHaveType(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, "io")
+	analyzer.AnalyzePackages(nil)
+	scope := analyzer.PackageByPath("io").PPkg.Types.Scope()
+
+	reader := analyzer.TryRegisteringType(scope.Lookup("Reader").Type(), false)
+	multiReader := scope.Lookup("multiReader").Type()
+	ptrMultiReader := analyzer.TryRegisteringType(types.NewPointer(multiReader), false)
+	if reader == nil || ptrMultiReader == nil {
+		t.Fatalf("io.Reader and *io.multiReader should be registered")
+	}
+	// MultiReader returns a *multiReader value as an io.Reader value.
+	if !analyzer.InterfaceValueMightHaveType(reader, ptrMultiReader) {
+		t.Errorf("*io.multiReader values should be assigned to io.Reader values")
+	}
+	if analyzer.InterfaceValueMightHaveType(reader, analyzer.TryRegisteringType(types.Typ[types.Int], false)) {
+		t.Errorf("int values should not be assigned to io.Reader values")
+	}
+}
+
+func TestObjectDocumentation(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, "strings")
+	analyzer.AnalyzePackages(nil)
+	pkg := analyzer.PackageByPath("strings").PPkg.Types
+
+	builder := pkg.Scope().Lookup("Builder")
+	if doc := analyzer.ObjectDocumentation(builder); !strings.HasPrefix(doc, "A Builder is used") {
+		t.Errorf("doc of strings.Builder is not found: %q", doc)
+	}
+	obj, _, _ := types.LookupFieldOrMethod(builder.Type(), true, pkg, "WriteString")
+	if doc := analyzer.ObjectDocumentation(obj); !strings.HasPrefix(doc, "WriteString appends") {
+		t.Errorf("doc of strings.Builder.WriteString is not found: %q", doc)
+	}
+	if doc := analyzer.ObjectDocumentation(types.Universe.Lookup("true")); doc != "" {
+		t.Errorf("objects not in the analyzed packages should have no docs")
+	}
+}
+
+func TestImplicitObjectReferences(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, "bufio")
+	analyzer.AnalyzePackages(nil)
+
+	var field types.Object
+	for _, tn := range analyzer.PackageByPath("bufio").AllTypeNames {
+		if tn.Name() == "ReadWriter" {
+			for _, sel := range tn.Denoting().AllFields {
+				if sel.Name() == "Reader" {
+					field = sel.Object()
+				}
+			}
+		}
+	}
+	if v, ok := field.(*types.Var); !ok || !v.Embedded() {
+		t.Fatalf("the embedded field bufio.ReadWriter.Reader is not found: %v", field)
+	}
+
+	// "&ReadWriter{r, w}" in bufio.NewReadWriter.
+	var numImplicits int
+	for _, id := range analyzer.ObjectReferences(field) {
+		if id.Implicit != nil {
+			numImplicits++
+		}
+	}
+	if numImplicits == 0 {
+		t.Errorf("the implicit uses of bufio.ReadWriter.Reader are not collected")
+	}
+}
+
+func TestInterfaceMethodDeclarations(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, "io")
+	analyzer.AnalyzePackages(nil)
+
+	for _, tn := range analyzer.PackageByPath("io").AllTypeNames {
+		if tn.Name() != "ReadWriteCloser" {
+			continue
+		}
+		for _, sel := range tn.Denoting().AllMethods {
+			decls := analyzer.InterfaceMethodDeclarations(tn, sel)
+			if len(decls) != 1 || decls[0].Interface == nil {
+				t.Fatalf("io.ReadWriteCloser.%s should be inherited from one interface: %v", sel.Name(), decls)
+			}
+			if expected := map[string]string{"Read": "Reader", "Write": "Writer", "Close": "Closer"}[sel.Name()]; decls[0].Interface.Name() != expected {
+				t.Errorf("io.ReadWriteCloser.%s should be inherited from io.%s, but got %s", sel.Name(), expected, decls[0].Interface.Name())
+			}
+		}
+		return
+	}
+	t.Fatal("io.ReadWriteCloser is not found")
+}
+
+func TestNestedInterfaceMethodDeclarations(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, "hash")
+	analyzer.AnalyzePackages(nil)
+
+	// hash.Hash32 embeds hash.Hash, which embeds io.Writer.
+	for _, tn := range analyzer.PackageByPath("hash").AllTypeNames {
+		if tn.Name() != "Hash32" {
+			continue
+		}
+		for _, sel := range tn.Denoting().AllMethods {
+			if sel.Name() != "Write" {
+				continue
+			}
+			decls := analyzer.InterfaceMethodDeclarations(tn, sel)
+			if len(decls) != 1 || decls[0].Interface == nil || decls[0].Interface.Name() != "Hash" {
+				t.Errorf("hash.Hash32.Write should be reported as inherited from hash.Hash: %v", decls)
+			}
+			return
+		}
+	}
+	t.Fatal("hash.Hash32.Write is not found")
+}
+
+func TestDeprecations(t *testing.T) {
+	if note := deprecationNote("F does f.\n\nDeprecated: use G.\n"); note != "Deprecated: use G." {
+		t.Errorf("deprecationNote got %q", note)
+	}
+	if note := deprecationNote("F is not Deprecated: at all.\n"); note != "" {
+		t.Errorf("deprecationNote got %q", note)
+	}
+
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, "io/ioutil")
+	analyzer.AnalyzePackages(nil)
+
+	readAll := analyzer.PackageByPath("io/ioutil").PPkg.Types.Scope().Lookup("ReadAll")
+	if note := analyzer.ObjectDeprecation(readAll); !strings.HasPrefix(note, "Deprecated: ") {
+		t.Errorf("io/ioutil.ReadAll should be deprecated: %q", note)
+	}
+	readAll = analyzer.PackageByPath("io").PPkg.Types.Scope().Lookup("ReadAll")
+	if note := analyzer.ObjectDeprecation(readAll); note != "" {
+		t.Errorf("io.ReadAll should not be deprecated: %q", note)
+	}
+}
+
+func TestDiffAPI(t *testing.T) {
+	var old = APISnapshot{
+		Packages: map[string]map[string]string{
+			"a": {"func F": "func()", "func G": "func()", "T implements io.Reader": "", "T implements x.I": ""},
+		},
+		Loaded: map[string]bool{"a": true, "io": true, "x": true},
+	}
+	var new = APISnapshot{
+		Packages: map[string]map[string]string{
+			"a": {"func F": "func(int)", "func H": "func()"},
+		},
+		Loaded: map[string]bool{"a": true, "io": true}, // x is not loaded
+	}
+	var expected = []APIChange{
+		{Package: "a", Declaration: "T implements io.Reader", Kind: "removed"},
+		{Package: "a", Declaration: "func F", Kind: "changed", Old: "func()", New: "func(int)"},
+		{Package: "a", Declaration: "func G", Kind: "removed", Old: "func()"},
+		{Package: "a", Declaration: "func H", Kind: "added", New: "func()"},
+	}
+	if changes := DiffAPI(old, new); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("DiffAPI got %v, expected %v", changes, expected)
+	}
+}
+
+func TestAPISnapshot(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, "bytes", "go/ast")
+	analyzer.AnalyzePackages(nil)
+	snapshot := analyzer.APISnapshot([]*Package{analyzer.PackageByPath("bytes"), analyzer.PackageByPath("go/ast")})
+	for decl, expected := range map[string]string{
+		"bytes.type Buffer":      "struct{/* unexported ones */}",
+		"go/ast.type Expr":       "interface{End() go/token.Pos; Pos() go/token.Pos; /* unexported ones */}",
+		"go/ast.type Scope":      "struct{Outer *go/ast.Scope; Objects map[string]*go/ast.Object}",
+		"go/ast.type CommentMap": "map[go/ast.Node][]*go/ast.CommentGroup",
+		"go/ast.method File.Pos": "(*File) func() go/token.Pos",
+	} {
+		i := strings.Index(decl, ".")
+		if got := snapshot.Packages[decl[:i]][decl[i+1:]]; got != expected {
+			t.Errorf("%s: got %q, expected %q", decl, got, expected)
+		}
+	}
+}
+
+func TestParseImportRules(t *testing.T) {
+	rules, err := ParseImportRules(strings.NewReader(`
+# layers
+forbid x.y/store/... x.y/http/...
+allow  x.y/store/... x.y/http/types
+`))
+	if err != nil {
+		t.Fatalf("ParseImportRules error: %s", err)
+	}
+	if len(rules) != 2 || rules[0].Allow || !rules[1].Allow || rules[0].Line != 3 || rules[1].Line != 4 {
+		t.Fatalf("ParseImportRules got %v", rules)
+	}
+	for _, c := range []struct {
+		pattern, path string
+		match         bool
+	}{
+		{"x.y/http/...", "x.y/http", true},
+		{"x.y/http/...", "x.y/http/types", true},
+		{"x.y/http/...", "x.y/https", false},
+		{"x.y/.../internal", "x.y/a/b/internal", true},
+		{"x.y/http", "x.y/http/types", false},
+	} {
+		if importPathPatternRegexp(c.pattern).MatchString(c.path) != c.match {
+			t.Errorf("pattern %s matching %s should be %v", c.pattern, c.path, c.match)
+		}
+	}
+
+	for _, bad := range []string{"forbid a", "deny a b"} {
+		if _, err := ParseImportRules(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseImportRules(%q) should fail", bad)
+		}
+	}
+}
+
 func TestRegisterType(t *testing.T) {
 	var analyzer CodeAnalyzer
 	var builtinType = func(name string) types.Type {
@@ -91,7 +619,10 @@ func TestAnalyzeStandardPackage(t *testing.T) {
 
 	var cache = &typeutil.MethodSetCache{}
 
-	for i := 0; i < len(analyzer.allTypeInfos); i++ {
+	// Types registered in the following checks are not analyzed.
+	var numAnalyzedTypes = len(analyzer.allTypeInfos)
+
+	for i := 0; i < numAnalyzedTypes; i++ {
 		ti := analyzer.allTypeInfos[i]
 		switch tt := ti.TT.(type) {
 		case *types.Interface:
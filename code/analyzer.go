@@ -29,6 +29,7 @@ const (
 	SubTask_CollectSourceFiles
 	SubTask_CollectObjectReferences
 	SubTask_CacheSourceFiles
+	SubTask_CollectExamples
 )
 
 type CodeAnalyzer struct {
@@ -40,6 +41,9 @@ type CodeAnalyzer struct {
 	packageList  []*Package
 	builtinPkg   *Package
 
+	// Specified by ParseOptions.Platforms.
+	platforms []Platform
+
 	// This one is removed now. We should use FileSet.PositionFor.
 	//sourceFileLineOffsetTable map[string]int32
 
@@ -74,6 +78,33 @@ type CodeAnalyzer struct {
 	// Identifer references (ToDo: need optimizations)
 	objectRefs map[types.Object][]Identifier
 
+	// Uses of exported objects in other packages.
+	objectUseStats map[types.Object]ObjectUseStat
+
+	// The call graph.
+	callsByCaller map[*types.Func][]FunctionCall
+	callsByCallee map[*types.Func][]FunctionCall
+
+	// The static interface types of the receivers at interface method call sites.
+	interfaceMethodCalls map[*ast.Ident]*TypeInfo
+
+	// The types of the values statically assigned to interface values.
+	interfaceValueSources map[*TypeInfo]map[*TypeInfo]struct{}
+	interfaceValueTypes   map[*TypeInfo]map[*TypeInfo]struct{} // lazily built
+
+	// Package-level declared objects, and fields and methods
+	// of package-level declared types, to their docs.
+	objectDocs map[types.Object]documented
+
+	// Deprecated objects to their "Deprecated: " paragraphs,
+	// and the uses of them, by the using packages.
+	deprecations   map[types.Object]string
+	deprecatedUses map[*Package][]DeprecatedUse
+
+	// Imports forbidden by import rules.
+	importViolations   []ImportViolation
+	importRulesChecked bool
+
 	// Not concurrent safe.
 	tempTypeLookup map[uint32]struct{}
 
@@ -196,6 +227,10 @@ type Identifier struct {
 
 	FileInfo *SourceFileInfo
 	AstIdent *ast.Ident
+
+	// Non-nil for implicit uses, such as the elements of unkeyed
+	// struct literals. AstIdent is a fake one at its position.
+	Implicit ast.Expr
 }
 
 func (d *CodeAnalyzer) regObjectReference(obj types.Object, fileInfo *SourceFileInfo, id *ast.Ident) {
@@ -211,6 +246,12 @@ func (d *CodeAnalyzer) regObjectReference(obj types.Object, fileInfo *SourceFile
 	d.objectRefs[obj] = ids
 }
 
+func (d *CodeAnalyzer) regImplicitObjectReference(obj types.Object, fileInfo *SourceFileInfo, expr ast.Expr) {
+	d.regObjectReference(obj, fileInfo, &ast.Ident{NamePos: expr.Pos(), Name: types.ExprString(expr)})
+	ids := d.objectRefs[obj]
+	ids[len(ids)-1].Implicit = expr
+}
+
 // Package by package, file by file, by positions in source.
 func (d *CodeAnalyzer) ObjectReferences(obj types.Object) []Identifier {
 	ids := d.objectRefs[obj]
@@ -250,6 +291,29 @@ func (d *CodeAnalyzer) PackageByPath(path string) *Package {
 	return d.packageTable[path]
 }
 
+// The first module is always the std module.
+func (d *CodeAnalyzer) NumModules() int {
+	return len(d.allModules)
+}
+
+func (d *CodeAnalyzer) ModuleAt(i int) *Module {
+	return d.allModules[i]
+}
+
+// Return nil if no modules are found.
+func (d *CodeAnalyzer) ModuleByPath(path string) *Module {
+	for _, m := range d.allModules {
+		if m.Root == path {
+			return m
+		}
+	}
+	return nil
+}
+
+func (d *CodeAnalyzer) IsStandardModule(m *Module) bool {
+	return m == d.stdModule
+}
+
 func (d *CodeAnalyzer) IsStandardPackage(pkg *Package) bool {
 	return pkg.Mod == d.stdModule
 }
@@ -356,6 +420,9 @@ func (d *CodeAnalyzer) RegisterType(t types.Type) *TypeInfo {
 //var numNameds, numNamedInterfaces = 0, 0
 
 func (d *CodeAnalyzer) TryRegisteringType(t types.Type, createOnNonexist bool) *TypeInfo {
+	// An alias and its denoted type share the same TypeInfo.
+	t = types.Unalias(t)
+
 	typeInfo, _ := d.ttype2TypeInfoTable.At(t).(*TypeInfo)
 	if typeInfo == nil && createOnNonexist {
 		if d.forbidRegisterTypes {
@@ -387,6 +454,9 @@ func (d *CodeAnalyzer) TryRegisteringType(t types.Type, createOnNonexist bool) *
 			//if _, ok := t.Underlying().(*types.Interface); ok {
 			//	numNamedInterfaces++
 			//}
+		case *types.TypeParam:
+			// The underlying type of a type parameter is its constraint.
+			typeInfo.Underlying = d.RegisterType(t.Underlying())
 		default:
 			typeInfo.Underlying = typeInfo
 		}
@@ -405,13 +475,13 @@ func (d *CodeAnalyzer) TryRegisteringType(t types.Type, createOnNonexist bool) *
 }
 
 func (d *CodeAnalyzer) RetrieveTypeName(t *TypeInfo) (*TypeName, bool) {
-	if tn := t.TypeName; tn != nil {
+	if tn := d.typeNameOf(t); tn != nil {
 		return tn, false
 	}
 
 	if ptt, ok := t.TT.(*types.Pointer); ok {
 		bt := d.RegisterType(ptt.Elem())
-		if btn := bt.TypeName; btn != nil {
+		if btn := d.typeNameOf(bt); btn != nil {
 			return btn, true
 		}
 
@@ -423,6 +493,18 @@ func (d *CodeAnalyzer) RetrieveTypeName(t *TypeInfo) (*TypeName, bool) {
 	return nil, false
 }
 
+// Instantiated types have no type names.
+// The type names of their generic origins are used instead.
+func (d *CodeAnalyzer) typeNameOf(t *TypeInfo) *TypeName {
+	if t.TypeName != nil {
+		return t.TypeName
+	}
+	if ntt, ok := t.TT.(*types.Named); ok && ntt.TypeArgs().Len() > 0 {
+		return d.RegisterType(ntt.Origin()).TypeName
+	}
+	return nil
+}
+
 // Methods contribute to type implementations.
 // The key is typeIndex << 32 | methodIndex.
 // typeIndex must be the index of a non-interface type.
@@ -536,6 +618,10 @@ func (d *CodeAnalyzer) iterateTypenames(typeLiteral ast.Expr, pkg *Package, onTy
 		d.iterateTypenames(node.Value, pkg, onTypeName)
 	case *ast.ChanType:
 		d.iterateTypenames(node.Value, pkg, onTypeName)
+	case *ast.IndexExpr: // instantiated types
+		d.iterateTypenames(node.X, pkg, onTypeName)
+	case *ast.IndexListExpr: // instantiated types
+		d.iterateTypenames(node.X, pkg, onTypeName)
 	// To avoid return too much weak-related results, the following types are ignored now.
 	case *ast.FuncType:
 	case *ast.StructType:
@@ -573,6 +659,17 @@ func (d *CodeAnalyzer) lookForAndRegisterUnnamedInterfaceAndStructTypes(typeLite
 	case *ast.MapType:
 		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.Key, pkg)
 		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.Value, pkg)
+	case *ast.BinaryExpr: // A | B in constraints
+		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.X, pkg)
+		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.Y, pkg)
+	case *ast.UnaryExpr: // ~T in constraints
+		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.X, pkg)
+	case *ast.IndexExpr: // instantiated types
+		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.Index, pkg)
+	case *ast.IndexListExpr: // instantiated types
+		for _, index := range node.Indices {
+			d.lookForAndRegisterUnnamedInterfaceAndStructTypes(index, pkg)
+		}
 	case *ast.StructType:
 		tv := pkg.PPkg.TypesInfo.Types[node]
 		typeInfo := d.RegisterType(tv.Type)
@@ -615,10 +712,19 @@ func (d *CodeAnalyzer) registerDirectFields(typeInfo *TypeInfo, astStructNode *a
 			var id string
 
 			var isStar = false
+			var instantiated types.Type
 			for ok, node := true, field.Type; ok; ok = isStar {
 				switch expr := node.(type) {
 				default:
 					panic("not an embedded field but should be. type: " + fmt.Sprintf("%T", expr))
+				case *ast.IndexExpr, *ast.IndexListExpr:
+					// An instantiated generic type.
+					instantiated = pkg.PPkg.TypesInfo.TypeOf(expr)
+					named, ok := instantiated.(*types.Named)
+					if !ok {
+						panic("not an instantiated named type: " + fmt.Sprintf("%T", instantiated))
+					}
+					id = d.Id2(named.Obj().Pkg(), named.Obj().Name())
 				case *ast.Ident:
 					//id = d.Id1b(pkg, expr.Name) // incorrect for builtin typenames
 
@@ -655,6 +761,9 @@ func (d *CodeAnalyzer) registerDirectFields(typeInfo *TypeInfo, astStructNode *a
 			if fieldTypeInfo == nil {
 				fieldTypeInfo = tn.Alias.Denoting
 			}
+			if instantiated != nil {
+				fieldTypeInfo = d.RegisterType(instantiated)
+			}
 			embedMode := EmbedMode_Direct
 			if isStar {
 				fieldTypeInfo = d.RegisterType(types.NewPointer(fieldTypeInfo.TT))
@@ -845,10 +954,23 @@ func (d *CodeAnalyzer) registerExplicitlySpecifiedMethods(typeInfo *TypeInfo, as
 
 		if len(method.Names) == 0 { // embed interface type (annoymous field)
 
+			// Since Go 1.18, an embedded element might be a type set term
+			// (~T, A | B, or a non-interface type), which contributes no methods.
+			tt := pkg.PPkg.TypesInfo.TypeOf(method.Type)
+			if tt == nil {
+				continue
+			}
+			if _, ok := tt.Underlying().(*types.Interface); !ok {
+				d.lookForAndRegisterUnnamedInterfaceAndStructTypes(method.Type, pkg)
+				continue
+			}
+
 			var id string
+			var instantiated bool
 			switch expr := method.Type.(type) {
 			default:
-				panic("not a valid embedding interface type name")
+				d.lookForAndRegisterUnnamedInterfaceAndStructTypes(method.Type, pkg)
+				continue
 			case *ast.Ident:
 				ttn := pkg.PPkg.TypesInfo.Uses[expr]
 				id = d.Id2(ttn.Pkg(), ttn.Name())
@@ -856,6 +978,13 @@ func (d *CodeAnalyzer) registerExplicitlySpecifiedMethods(typeInfo *TypeInfo, as
 				srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr.X.(*ast.Ident))
 				srcPkg := srcObj.(*types.PkgName)
 				id = d.Id2(srcPkg.Imported(), expr.Sel.Name)
+			case *ast.IndexExpr, *ast.IndexListExpr: // an instantiated generic interface
+				named, ok := tt.(*types.Named)
+				if !ok {
+					continue
+				}
+				id = d.Id2(named.Obj().Pkg(), named.Obj().Name())
+				instantiated = true
 			}
 
 			tn := d.allTypeNameTable[id]
@@ -867,6 +996,9 @@ func (d *CodeAnalyzer) registerExplicitlySpecifiedMethods(typeInfo *TypeInfo, as
 			if fieldTypeInfo == nil {
 				fieldTypeInfo = tn.Alias.Denoting
 			}
+			if instantiated {
+				fieldTypeInfo = d.RegisterType(tt)
+			}
 			embedMode := EmbedMode_Direct
 
 			//if strings.Index(id, "image") >= 0 {
@@ -924,6 +1056,183 @@ func (d *CodeAnalyzer) registerExplicitlySpecifiedMethods(typeInfo *TypeInfo, as
 	//log.Println("       registerExplicitlySpecifiedMethods:", len(typeInfo.DirectSelectors))
 }
 
+// Some interface types have no AST representations, such as the implicit
+// interfaces of constraints ([T ~int]) and the underlying types of
+// instantiated generic interface types. Their direct selectors are built
+// from go/types info instead. The returned result indicates whether or not
+// t is an interface type.
+func (d *CodeAnalyzer) registerInterfaceSelectorsFromTypesInfo(t *TypeInfo) bool {
+	itt, ok := t.TT.Underlying().(*types.Interface)
+	if !ok {
+		return false
+	}
+	t.attributes |= directSelectorsCollected
+
+	pkgOf := func(p *types.Package) *Package {
+		if p == nil {
+			return nil
+		}
+		return d.PackageByPath(p.Path())
+	}
+
+	for i := 0; i < itt.NumEmbeddeds(); i++ {
+		et := itt.EmbeddedType(i)
+		named, ok := et.(*types.Named)
+		if !ok {
+			continue // type set terms
+		}
+		if _, ok := named.Underlying().(*types.Interface); !ok {
+			continue
+		}
+		pkg := pkgOf(named.Obj().Pkg())
+		field := &Field{
+			Pkg:  pkg,
+			Name: named.Obj().Name(),
+			Type: d.RegisterType(named),
+			Mode: EmbedMode_Direct,
+		}
+		t.DirectSelectors = append(t.DirectSelectors, &Selector{
+			Id:    d.Id1b(pkg, field.Name),
+			Field: field,
+		})
+	}
+
+	for i := 0; i < itt.NumExplicitMethods(); i++ {
+		m := itt.ExplicitMethod(i)
+		var pkg *Package
+		if !m.Exported() {
+			pkg = pkgOf(m.Pkg())
+		}
+		method := &Method{
+			Pkg:  pkg,
+			Name: m.Name(),
+			Type: d.RegisterType(m.Type()),
+		}
+		t.DirectSelectors = append(t.DirectSelectors, &Selector{
+			Id:     d.Id1b(pkg, method.Name),
+			Method: method,
+		})
+	}
+
+	return true
+}
+
+// Instantiated types have no AST representations. Their direct selectors
+// are built from go/types info, with the AST nodes of the generic types.
+// Unnamed struct types created in type instantiations are also handled here.
+func (d *CodeAnalyzer) registerSelectorsForInstantiatedType(t *TypeInfo) {
+	if (t.attributes & directSelectorsCollected) != 0 {
+		return
+	}
+
+	pkgOf := func(p *types.Package) *Package {
+		if p == nil {
+			return nil
+		}
+		return d.PackageByPath(p.Path())
+	}
+
+	var registerFields = func(t *TypeInfo, st *types.Struct, origin *TypeInfo) {
+		t.attributes |= directSelectorsCollected
+		for i := 0; i < st.NumFields(); i++ {
+			f := st.Field(i)
+			if f.Name() == "_" {
+				continue
+			}
+			var pkg = pkgOf(f.Pkg())
+			field := &Field{
+				Pkg:  pkg,
+				Name: f.Name(),
+				Type: d.RegisterType(f.Type()),
+				Mode: EmbedMode_None,
+			}
+			if f.Embedded() {
+				field.Mode = EmbedMode_Direct
+				if _, ok := f.Type().(*types.Pointer); ok {
+					field.Mode = EmbedMode_Indirect
+				}
+				t.EmbeddingFields++
+			}
+			if tag := st.Tag(i); tag != "" {
+				field.Tag = "`" + tag + "`"
+			}
+			if origin != nil {
+				for _, sel := range origin.DirectSelectors {
+					if sel.Field != nil && sel.Field.Name == field.Name {
+						field.astStruct = sel.Field.astStruct
+						field.AstField = sel.Field.AstField
+						field.Pkg = sel.Field.Pkg
+						field.Tag = sel.Field.Tag
+						break
+					}
+				}
+			}
+			if field.AstField == nil && !token.IsExported(field.Name) && pkg == nil {
+				continue
+			}
+			t.DirectSelectors = append(t.DirectSelectors, &Selector{
+				Id:    d.Id1b(field.Pkg, field.Name),
+				Field: field,
+			})
+		}
+	}
+
+	switch tt := t.TT.(type) {
+	case *types.Named:
+		if tt.TypeArgs().Len() == 0 {
+			return
+		}
+		t.attributes |= directSelectorsCollected
+
+		// Named types declared in source code get their pointer types
+		// and method parameter types registered in earlier phases.
+		d.RegisterType(types.NewPointer(tt))
+
+		origin := d.RegisterType(tt.Origin())
+		for i := 0; i < tt.NumMethods(); i++ {
+			m := tt.Method(i)
+			method := &Method{
+				Pkg:          pkgOf(m.Pkg()),
+				Name:         m.Name(),
+				Type:         d.RegisterType(m.Type()),
+				Instantiated: true,
+			}
+			if sig, ok := m.Type().(*types.Signature); ok {
+				if sig.Recv() != nil {
+					_, method.PointerRecv = sig.Recv().Type().(*types.Pointer)
+				}
+				for k := 0; k < sig.Params().Len(); k++ {
+					d.RegisterType(sig.Params().At(k).Type())
+				}
+				for k := 0; k < sig.Results().Len(); k++ {
+					d.RegisterType(sig.Results().At(k).Type())
+				}
+			}
+			for _, sel := range origin.DirectSelectors {
+				if sel.Method != nil && sel.Method.Name == method.Name {
+					method.Pkg = sel.Method.Pkg
+					method.AstFunc = sel.Method.AstFunc
+					method.AstField = sel.Method.AstField
+					method.PointerRecv = sel.Method.PointerRecv
+					break
+				}
+			}
+			t.DirectSelectors = append(t.DirectSelectors, &Selector{
+				Id:     d.Id1b(method.Pkg, method.Name),
+				Method: method,
+			})
+		}
+
+		if st, ok := tt.Underlying().(*types.Struct); ok {
+			if ut := d.RegisterType(st); (ut.attributes & directSelectorsCollected) == 0 {
+				registerFields(ut, st, origin.Underlying)
+			}
+		}
+	case *types.Struct:
+		registerFields(t, tt, nil)
+	}
+}
+
 // ToDo: to loop parameter and result lists and use AST to constract custom methods.
 func (d *CodeAnalyzer) registerExplicitlyDeclaredMethod(f *Function) {
 	funcObj, funcDecl, pkg := f.Func, f.AstDecl, f.Pkg
@@ -955,6 +1264,9 @@ func (d *CodeAnalyzer) registerExplicitlyDeclaredMethod(f *Function) {
 	default:
 		panic("impossible")
 	}
+	// For generic types, the receiver base type is instantiated
+	// with the receiver type parameters.
+	baseTT = baseTT.Origin()
 
 	// ToDo: using sig.Params() and sig.Results() instead of funcObj.Type()
 
@@ -0,0 +1,231 @@
+package code
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// An APISnapshot records the exported APIs of some packages, so that
+// the APIs of two versions of the packages may be compared.
+type APISnapshot struct {
+	// Package paths to declarations, and declarations to their
+	// descriptions. The declarations look like "type T", "func F",
+	// "var V", "const C", "method T.M" and "T implements io.Reader".
+	Packages map[string]map[string]string
+
+	// All the loaded packages. Only the interfaces in
+	// them are checked for implementation relations.
+	Loaded map[string]bool
+}
+
+// An APIChange is a difference between two API snapshots.
+type APIChange struct {
+	Package     string
+	Declaration string
+	Kind        string // "added", "removed" or "changed"
+	Old         string `json:",omitempty"` // blank for added ones
+	New         string `json:",omitempty"` // blank for removed ones
+}
+
+// Package paths are used as type qualifiers, so that
+// the descriptions in two snapshots are comparable.
+func apiTypeString(tt types.Type) string {
+	return types.TypeString(tt, func(p *types.Package) string {
+		return p.Path()
+	})
+}
+
+// Unexported fields and methods of the underlying type of a declared type
+// are not APIs. Only whether or not there are such ones is recorded, for
+// it affects how values of the type may be used.
+func apiUnderlyingString(tt types.Type) string {
+	var filtered types.Type
+	switch tt := tt.(type) {
+	case *types.Struct:
+		var fields []*types.Var
+		var tags []string
+		for i := 0; i < tt.NumFields(); i++ {
+			if f := tt.Field(i); f.Exported() {
+				fields = append(fields, f)
+				tags = append(tags, tt.Tag(i))
+			}
+		}
+		if len(fields) < tt.NumFields() {
+			filtered = types.NewStruct(fields, tags)
+		}
+	case *types.Interface:
+		if !tt.IsMethodSet() {
+			break // constraints
+		}
+		var methods []*types.Func
+		for i := 0; i < tt.NumMethods(); i++ {
+			if m := tt.Method(i); m.Exported() {
+				methods = append(methods, m)
+			}
+		}
+		if len(methods) < tt.NumMethods() {
+			filtered = types.NewInterfaceType(methods, nil).Complete()
+		}
+	}
+	if filtered == nil {
+		return apiTypeString(tt)
+	}
+	str := strings.TrimSuffix(apiTypeString(filtered), "}")
+	if !strings.HasSuffix(str, "{") {
+		str += "; "
+	}
+	return str + "/* unexported ones */}"
+}
+
+// Parameter names are omitted, for renaming them doesn't change APIs.
+func apiSignatureString(tt types.Type) string {
+	sig, ok := tt.(*types.Signature)
+	if !ok {
+		return apiTypeString(tt)
+	}
+	unname := func(tuple *types.Tuple) *types.Tuple {
+		vars := make([]*types.Var, tuple.Len())
+		for i := range vars {
+			vars[i] = types.NewParam(token.NoPos, nil, "", tuple.At(i).Type())
+		}
+		return types.NewTuple(vars...)
+	}
+	unnamed := types.NewSignatureType(nil, nil, nil, unname(sig.Params()), unname(sig.Results()), sig.Variadic())
+	return apiTypeParamsString(sig.TypeParams()) + apiTypeString(unnamed)
+}
+
+// Such as "[K comparable, V any] ". Blank for non-generic declarations.
+func apiTypeParamsString(tparams *types.TypeParamList) string {
+	if tparams.Len() == 0 {
+		return ""
+	}
+	list := make([]string, tparams.Len())
+	for i := range list {
+		tp := tparams.At(i)
+		list[i] = tp.Obj().Name() + " " + apiTypeString(tp.Constraint())
+	}
+	return "[" + strings.Join(list, ", ") + "] "
+}
+
+// APISnapshot records the exported APIs of the specified packages.
+// Test-only declarations are ignored.
+func (d *CodeAnalyzer) APISnapshot(pkgs []*Package) APISnapshot {
+	snapshot := APISnapshot{
+		Packages: make(map[string]map[string]string, len(pkgs)),
+		Loaded:   make(map[string]bool, len(d.packageList)),
+	}
+	for _, pkg := range d.packageList {
+		snapshot.Loaded[pkg.Path()] = true
+	}
+	for _, pkg := range pkgs {
+		decls := make(map[string]string, 128)
+		snapshot.Packages[pkg.Path()] = decls
+		decls["package "+pkg.PPkg.Name] = ""
+
+		for _, tn := range pkg.AllTypeNames {
+			if !tn.Exported() || tn.TestOnly() {
+				continue
+			}
+			if tn.Alias != nil {
+				decls["type "+tn.Name()] = "= " + apiTypeString(tn.Denoting().TT)
+				continue
+			}
+			t := tn.Denoting()
+			var tparams *types.TypeParamList
+			if named, ok := t.TT.(*types.Named); ok {
+				tparams = named.TypeParams()
+			}
+			decls["type "+tn.Name()] = apiTypeParamsString(tparams) + apiUnderlyingString(t.TT.Underlying())
+			if _, ok := t.TT.Underlying().(*types.Interface); ok {
+				continue // methods are included in the underlying type
+			}
+			for _, sel := range t.AllMethods {
+				if !token.IsExported(sel.Name()) || sel.Method.Type == nil {
+					continue
+				}
+				recv := tn.Name()
+				if sel.PointerReceiverOnly() {
+					recv = "*" + recv
+				}
+				decls["method "+tn.Name()+"."+sel.Name()] = "(" + recv + ") " + apiSignatureString(sel.Method.Type.TT)
+			}
+			for _, impl := range d.CleanImplements(t) {
+				if itn := impl.Interface.TypeName; itn != nil && itn.Exported() {
+					impler := tn.Name()
+					if _, ok := impl.Impler.TT.(*types.Pointer); ok {
+						impler = "*" + impler
+					}
+					decls[impler+" implements "+itn.Package().Path()+"."+itn.Name()] = ""
+				}
+			}
+		}
+		for _, f := range pkg.AllFunctions {
+			if f.Exported() && !f.IsMethod() && !f.TestOnly() {
+				decls["func "+f.Name()] = apiSignatureString(f.TType())
+			}
+		}
+		for _, v := range pkg.AllVariables {
+			if v.Exported() && !v.TestOnly() {
+				decls["var "+v.Name()] = apiTypeString(v.TType())
+			}
+		}
+		for _, c := range pkg.AllConstants {
+			if c.Exported() && !c.TestOnly() {
+				decls["const "+c.Name()] = apiTypeString(c.TType()) + " = " + c.Val().ExactString()
+			}
+		}
+	}
+	return snapshot
+}
+
+// DiffAPI compares two API snapshots. The changes are sorted by
+// package paths then by declarations.
+func DiffAPI(old, new APISnapshot) []APIChange {
+	// An interface not loaded in a snapshot is
+	// not checked for implementations in it.
+	comparable := func(decl string) bool {
+		i := strings.Index(decl, " implements ")
+		if i < 0 {
+			return true
+		}
+		itPkgPath := decl[i+len(" implements "):]
+		itPkgPath = itPkgPath[:strings.LastIndexByte(itPkgPath, '.')]
+		return old.Loaded[itPkgPath] && new.Loaded[itPkgPath]
+	}
+
+	var changes []APIChange
+	for pkgPath, oldDecls := range old.Packages {
+		newDecls := new.Packages[pkgPath]
+		for decl, oldDesc := range oldDecls {
+			newDesc, ok := newDecls[decl]
+			if !ok {
+				if newDecls != nil && !comparable(decl) {
+					continue
+				}
+				changes = append(changes, APIChange{Package: pkgPath, Declaration: decl, Kind: "removed", Old: oldDesc})
+			} else if newDesc != oldDesc {
+				changes = append(changes, APIChange{Package: pkgPath, Declaration: decl, Kind: "changed", Old: oldDesc, New: newDesc})
+			}
+		}
+	}
+	for pkgPath, newDecls := range new.Packages {
+		oldDecls := old.Packages[pkgPath]
+		for decl, newDesc := range newDecls {
+			if _, ok := oldDecls[decl]; !ok {
+				if oldDecls != nil && !comparable(decl) {
+					continue
+				}
+				changes = append(changes, APIChange{Package: pkgPath, Declaration: decl, Kind: "added", New: newDesc})
+			}
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Package != changes[j].Package {
+			return changes[i].Package < changes[j].Package
+		}
+		return changes[i].Declaration < changes[j].Declaration
+	})
+	return changes
+}
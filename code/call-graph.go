@@ -0,0 +1,223 @@
+package code
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+)
+
+// A FunctionCall is a call to a function or method in the body of a
+// package-level function or method (including the function literals in it).
+type FunctionCall struct {
+	Caller *types.Func
+	Callee *types.Func
+
+	// For calls through interface dispatch, Callee is a concrete method
+	// which might be called, and Via is the called interface method.
+	// For other calls, Via is nil.
+	Via *types.Func
+
+	Identifier // the callee identifier at the call site
+}
+
+// collectFunctionCalls builds the call graph from the typed ASTs.
+// It is called after type implementations are found.
+// ToDo: also collect the calls in package-level variable initializers.
+func (d *CodeAnalyzer) collectFunctionCalls() {
+	d.callsByCaller = make(map[*types.Func][]FunctionCall, 4096)
+	d.callsByCallee = make(map[*types.Func][]FunctionCall, 4096)
+	d.interfaceMethodCalls = make(map[*ast.Ident]*TypeInfo, 1024)
+	var dispatchCache = make(map[[2]interface{}][]*types.Func, 1024)
+
+	var register = func(call FunctionCall) {
+		d.callsByCaller[call.Caller] = append(d.callsByCaller[call.Caller], call)
+		d.callsByCallee[call.Callee] = append(d.callsByCallee[call.Callee], call)
+	}
+
+	for _, pkg := range d.packageList {
+		for i := range pkg.SourceFiles {
+			info := &pkg.SourceFiles[i]
+			if info.AstFile == nil {
+				continue
+			}
+			for _, decl := range info.AstFile.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				caller, ok := pkg.PPkg.TypesInfo.Defs[fd.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				ast.Inspect(fd.Body, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					callee, ident, dispatchType := calleeOf(pkg.PPkg.TypesInfo, call)
+					if callee == nil {
+						return true
+					}
+					id := Identifier{FileInfo: info, AstIdent: ident}
+					register(FunctionCall{Caller: caller, Callee: callee, Identifier: id})
+					if dispatchType == nil {
+						return true
+					}
+					itype := d.TryRegisteringType(dispatchType, false)
+					if itype != nil {
+						d.interfaceMethodCalls[ident] = itype
+					}
+					key := [2]interface{}{itype, callee}
+					impls, cached := dispatchCache[key]
+					if !cached {
+						impls = d.interfaceMethodImplementations(itype, callee)
+						dispatchCache[key] = impls
+					}
+					for _, impl := range impls {
+						register(FunctionCall{Caller: caller, Callee: impl, Via: callee, Identifier: id})
+					}
+					return true
+				})
+			}
+		}
+	}
+
+	for _, calls := range d.callsByCallee {
+		sortFunctionCalls(calls)
+	}
+	for _, calls := range d.callsByCaller {
+		sortFunctionCalls(calls)
+	}
+}
+
+// By the positions of call sites.
+func sortFunctionCalls(calls []FunctionCall) {
+	sort.SliceStable(calls, func(i, j int) bool {
+		a, b := calls[i].FileInfo, calls[j].FileInfo
+		if a.Pkg != b.Pkg {
+			return a.Pkg.Path() < b.Pkg.Path()
+		}
+		if a != b {
+			return a.AstBareFileName() < b.AstBareFileName()
+		}
+		return calls[i].AstIdent.Pos() < calls[j].AstIdent.Pos()
+	})
+}
+
+// calleeOf returns the function or method called by a call expression.
+// For interface method calls, the static interface type is also returned.
+// Calls of function values, builtin functions and type conversions are ignored.
+func calleeOf(info *types.Info, call *ast.CallExpr) (callee *types.Func, ident *ast.Ident, dispatchType types.Type) {
+	fun := ast.Unparen(call.Fun)
+	switch e := fun.(type) { // generic function instantiations
+	case *ast.IndexExpr:
+		fun = ast.Unparen(e.X)
+	case *ast.IndexListExpr:
+		fun = ast.Unparen(e.X)
+	}
+
+	switch e := fun.(type) {
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		ident = e.Sel
+		if sel, ok := info.Selections[e]; ok {
+			if sel.Kind() == types.FieldVal {
+				return nil, nil, nil
+			}
+			if types.IsInterface(sel.Recv()) {
+				dispatchType = sel.Recv()
+			}
+		}
+	default:
+		return nil, nil, nil
+	}
+
+	f, ok := info.Uses[ident].(*types.Func)
+	if !ok {
+		return nil, nil, nil
+	}
+	return f.Origin(), ident, dispatchType
+}
+
+// The concrete methods implementing an interface method
+// for the types implementing the interface type.
+func (d *CodeAnalyzer) interfaceMethodImplementations(itype *TypeInfo, method *types.Func) []*types.Func {
+	if itype == nil {
+		return nil
+	}
+	var impls []*types.Func
+	var listed = make(map[*types.Func]bool, len(itype.ImplementedBys))
+	for _, impler := range itype.ImplementedBys {
+		if types.IsInterface(impler.TT) {
+			continue
+		}
+		obj, _, _ := types.LookupFieldOrMethod(impler.TT, false, method.Pkg(), method.Name())
+		f, ok := obj.(*types.Func)
+		if !ok {
+			continue
+		}
+		// The method might be promoted from an embedded interface field,
+		// in which case it is abstract and shouldn't be viewed as a callee.
+		if recv := f.Type().(*types.Signature).Recv(); recv == nil || types.IsInterface(recv.Type()) {
+			continue
+		}
+		f = f.Origin()
+		if !listed[f] {
+			listed[f] = true
+			impls = append(impls, f)
+		}
+	}
+	sort.Slice(impls, func(i, j int) bool {
+		a, b := impls[i].Pkg().Path(), impls[j].Pkg().Path()
+		if a != b {
+			return a < b
+		}
+		return FunctionPathName(impls[i]) < FunctionPathName(impls[j])
+	})
+	return impls
+}
+
+// FunctionCallers returns the calls to a function or method, including the
+// calls through interface dispatch. The calls are sorted by call sites.
+func (d *CodeAnalyzer) FunctionCallers(f *types.Func) []FunctionCall {
+	return d.callsByCallee[f]
+}
+
+// FunctionCallees returns the calls in the body of a function or method.
+// For a call through interface dispatch, besides the call to the interface
+// method, the possible calls to the concrete methods are also included.
+// The calls are sorted by call sites.
+func (d *CodeAnalyzer) FunctionCallees(f *types.Func) []FunctionCall {
+	return d.callsByCaller[f]
+}
+
+// InterfaceMethodCallType returns the static interface type of the receiver
+// if ident is the method name at an interface method call site. Otherwise,
+// nil is returned.
+func (d *CodeAnalyzer) InterfaceMethodCallType(ident *ast.Ident) *TypeInfo {
+	return d.interfaceMethodCalls[ident]
+}
+
+// FunctionPathName returns the name of a package-level function, or "T.M"
+// for a method declared for type T. Blank is returned for init functions
+// and the methods of unnamed and local types, which can't be looked up.
+func FunctionPathName(f *types.Func) string {
+	sig, ok := f.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		if f.Name() == "init" || f.Name() == "_" {
+			return ""
+		}
+		return f.Name()
+	}
+	recvType := sig.Recv().Type()
+	if ptr, ok := recvType.(*types.Pointer); ok {
+		recvType = ptr.Elem()
+	}
+	if named, ok := types.Unalias(recvType).(*types.Named); ok {
+		if obj := named.Obj(); obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope() {
+			return obj.Name() + "." + f.Name()
+		}
+	}
+	return ""
+}
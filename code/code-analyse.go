@@ -4,13 +4,22 @@ import (
 	"container/list"
 	"fmt"
 	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/parser"
 	"go/token"
 	"go/types"
+	"io/ioutil"
 	"log"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/go/types/typeutil"
 
 	"go101.org/golds/internal/util"
@@ -83,6 +92,11 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 	logProgress(SubTask_CollectSourceFiles)
 
 	d.CollectObjectReferences()
+	d.collectExportedObjectUses()
+	d.collectFunctionCalls()
+	d.collectInterfaceValueSources()
+	d.collectObjectDocumentations()
+	d.collectDeprecations()
 
 	logProgress(SubTask_CollectObjectReferences)
 
@@ -90,6 +104,12 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 
 	logProgress(SubTask_CacheSourceFiles)
 
+	for _, pkg := range d.packageList {
+		d.analyzePackage_CollectExamples(pkg)
+	}
+
+	logProgress(SubTask_CollectExamples)
+
 	d.analyzePackage_CollectSomeRuntimeFunctionPositions()
 
 	logProgress(SubTask_CollectRuntimeFunctionPositions)
@@ -362,6 +382,11 @@ func (d *CodeAnalyzer) analyzePackages_FindImplementations() { // (resultMethodC
 	var searchRound uint32 = 0
 	interfaceUnderlyings.Iterate(func(_ types.Type, info interface{}) {
 		uiInfo := info.(*UnderlyingInterfaceInfo)
+		if len(uiInfo.methodIndexes) == 0 {
+			// Selectors of some interface types (instantiated
+			// from generic ones) might be not collected.
+			return
+		}
 
 		typeIndexes := method2TypeIndexes[uiInfo.methodIndexes[0]]
 		for _, typeIndex := range typeIndexes {
@@ -472,6 +497,8 @@ func (d *CodeAnalyzer) analyzePackages_FindImplementations() { // (resultMethodC
 		}
 	})
 
+	d.findGenericInterfaceImplementations()
+
 	//for _, t := range d.allTypeInfos {
 	//	if len(t.Implements) > 0 {
 	//		log.Println(t.TT, "implements:")
@@ -679,6 +706,11 @@ func (d *CodeAnalyzer) analyzePackages_FindImplementations_Old() (resultMethodCa
 	var searchRound uint32 = 0
 	interfaceUnderlyings.Iterate(func(_ types.Type, info interface{}) {
 		uiInfo := info.(*UnderlyingInterfaceInfo)
+		if len(uiInfo.methodIndexes) == 0 {
+			// Selectors of some interface types (instantiated
+			// from generic ones) might be not collected.
+			return
+		}
 
 		typeIndexes := method2TypeIndexes[uiInfo.methodIndexes[0]]
 		for _, typeIndex := range typeIndexes {
@@ -927,10 +959,18 @@ func (d *CodeAnalyzer) analyzePackages_CollectSelectors() {
 		d.collectSelectorsForInterfaceType(t, 0, currentCounter, smm)
 	}
 
+	// Some types (mainly instantiated ones) are registered in the following loop.
+	var numInterfaceCheckedTypes = len(d.allTypeInfos)
+
 	var checkedTypes = make(map[uint32]uint16) // type index: embedding depth
 	for i := 0; i < len(d.allTypeInfos); i++ {
 		t := d.allTypeInfos[i]
 
+		if i >= numInterfaceCheckedTypes {
+			currentCounter++
+			d.collectSelectorsForInterfaceType(t, 0, currentCounter, smm)
+		}
+
 		//currentCounter++ // can't replace map
 
 		d.collectSelectorsFroNonInterfaceType(t, smm, checkedTypes)
@@ -1082,9 +1122,11 @@ func (d *CodeAnalyzer) collectSelectorsForInterfaceType(t *TypeInfo, depth int,
 
 		//log.Println("222", depth)
 		if (t.Underlying.attributes & directSelectorsCollected) == 0 {
-			panic("unnamed interface should have collected direct selectors now. " +
-				fmt.Sprintf("underlying index: %v. index: %v. name: %#v. %#v. %v",
-					t.Underlying.index, t.index, t.TypeName.Name(), t.Underlying.TT, t.TT.Underlying()))
+			if !d.registerInterfaceSelectorsFromTypesInfo(t.Underlying) {
+				panic("unnamed interface should have collected direct selectors now. " +
+					fmt.Sprintf("underlying index: %v. index: %v. name: %#v. %#v. %v",
+						t.Underlying.index, t.index, t.TypeName.Name(), t.Underlying.TT, t.TT.Underlying()))
+			}
 		}
 		//if t.DirectSelectors != nil {
 		//	panic("Selectors of named interface should be blank now")
@@ -1103,7 +1145,7 @@ func (d *CodeAnalyzer) collectSelectorsForInterfaceType(t *TypeInfo, depth int,
 		//if debug {
 		//	log.Println("333", depth)
 		//}
-		if (t.Underlying.attributes & directSelectorsCollected) == 0 {
+		if (t.Underlying.attributes&directSelectorsCollected) == 0 && !d.registerInterfaceSelectorsFromTypesInfo(t) {
 			//if depth == 0 {
 			//	return // ToDo: temp ignore field and paramter/result unnamed interface types
 			//}
@@ -1222,6 +1264,8 @@ func (d *CodeAnalyzer) collectSelectorsFroNonInterfaceType(t *TypeInfo, smm *Sel
 	var namedType *TypeInfo
 	var structType *TypeInfo
 
+	d.registerSelectorsForInstantiatedType(t)
+
 	switch t.TT.(type) {
 	case *types.Named:
 		namedType = t
@@ -1421,6 +1465,7 @@ func (d *CodeAnalyzer) collectSelectorsFroNonInterfaceType(t *TypeInfo, smm *Sel
 			}
 
 			//log.Println("       000")
+			d.registerSelectorsForInstantiatedType(embeddedField.Field.Type)
 			switch t := embeddedField.Field.Type; tt := t.TT.(type) {
 			case *types.Named:
 				switch t.Underlying.TT.(type) {
@@ -1453,6 +1498,7 @@ func (d *CodeAnalyzer) collectSelectorsFroNonInterfaceType(t *TypeInfo, smm *Sel
 			case *types.Pointer:
 				//log.Println("       444")
 				baseType := d.RegisterType(tt.Elem())
+				d.registerSelectorsForInstantiatedType(baseType)
 				switch baseTT := baseType.TT.(type) {
 				case *types.Struct:
 					//log.Println("       444 aaa")
@@ -1555,7 +1601,6 @@ func (d *CodeAnalyzer) collectSelectorsFroNonInterfaceType(t *TypeInfo, smm *Sel
 	}
 }
 
-// ToDo
 func (d *CodeAnalyzer) confirmPackageModules() {
 	// Two cases:
 	// 1. check the .../vendor/modules.txt files
@@ -1597,48 +1642,81 @@ func (d *CodeAnalyzer) confirmPackageModules() {
 	// # find GOROOT to find std module info
 	// go env
 
-	//findPkgModule := func(pkg *Package) {
-	//	// d.stdPackages
-	//}
-	//_ = findPkgModule
+	// The module info confirmed in this funciton is only for showing now.
+	// It might be also used to calculate the distances of pacakges later.
+	// However, it might be not perfect to determine the distance of
+	// two packages by checking if they are in the same module.
 
-	//for _, pkg := range d.packageList {
-	//	if len(pkg.PPkg.GoFiles) == 0 {
-	//		continue
-	//	}
-	//	dir := filepath.Dir(pkg.PPkg.GoFiles[0])
-	//	filename := filepath.Join(dir, "go.mod")
-	//	filedata, err := ioutil.ReadFile(filename)
-	//	if err != nil {
-	//		if !errors.Is(err, os.ErrNotExist) {
-	//			log.Printf("ioutil.ReadFile %s error: %s", filename, err)
-	//		}
-	//		continue
-	//	}
-	//
-	//	modFile, err := modfile.ParseLax(filename, filedata, nil)
-	//	if err != nil {
-	//	}
-	//
-	//	mod := Module{
-	//		Dir:     dir,
-	//		Root:    modFile.Module.Mod.Path,
-	//		Version: modFile.Module.Mod.Version,
-	//	}
-	//
-	//	_ = mod
-	//}
+	var moduleTable = make(map[string]*Module, cap(d.allModules))
+	for _, pkg := range d.packageList {
+		if pkg.Mod == d.stdModule {
+			d.stdModule.Pkgs = append(d.stdModule.Pkgs, pkg)
+			continue
+		}
 
-	// I decided to delay the impplementation of this funciton now.
-	// One intention to confirm module information is
-	// to support module pages, but this is not very essential.
-	// Another intention to confirm module information
-	// is to calculate the distances of pacakges.
-	// However, it might be not perfect to determine
-	// the distance of two packages by checking if
-	// they are in the same module.
-	//
-	// The module info confirmed in this funciton will be only for showing.
+		pm := pkg.PPkg.Module
+		if pm == nil { // GOPATH mode
+			continue
+		}
+
+		mod := moduleTable[pm.Path]
+		if mod == nil {
+			mod = &Module{
+				Dir:     pm.Dir,
+				Root:    pm.Path,
+				Version: pm.Version,
+				GoMod:   pm.GoMod,
+			}
+			if r := pm.Replace; r != nil {
+				mod.Replace = &Module{
+					Dir:     r.Dir,
+					Root:    r.Path,
+					Version: r.Version,
+				}
+			}
+			moduleTable[pm.Path] = mod
+			d.allModules = append(d.allModules, mod)
+		}
+		pkg.Mod = mod
+		mod.Pkgs = append(mod.Pkgs, pkg)
+	}
+
+	for _, mod := range d.allModules {
+		if mod.GoMod == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(mod.GoMod)
+		if err != nil {
+			log.Printf("read %s error: %s", mod.GoMod, err)
+			continue
+		}
+		modFile, err := modfile.ParseLax(mod.GoMod, data, nil)
+		if err != nil {
+			log.Printf("parse %s error: %s", mod.GoMod, err)
+			continue
+		}
+		for _, r := range modFile.Require {
+			if dep := moduleTable[r.Mod.Path]; dep != nil && dep != mod {
+				mod.Requires = append(mod.Requires, dep)
+				dep.RequiredBys = append(dep.RequiredBys, mod)
+			}
+		}
+	}
+
+	sortModules := func(mods []*Module) {
+		sort.Slice(mods, func(i, j int) bool {
+			return mods[i].Root < mods[j].Root
+		})
+	}
+	for _, mod := range d.allModules {
+		sort.Slice(mod.Pkgs, func(i, j int) bool {
+			return mod.Pkgs[i].Path() < mod.Pkgs[j].Path()
+		})
+		sortModules(mod.Requires)
+		sortModules(mod.RequiredBys)
+	}
+	// The std module is always the first one.
+	sortModules(d.allModules[1:])
 }
 
 // Important for registerFunctionForInvolvedTypeNames and registerValueForItsTypeName.
@@ -1789,7 +1867,13 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 						}
 
 						tv := pkg.PPkg.TypesInfo.Types[typeSpec.Type]
-						if !tv.IsType() {
+						if !tv.IsType() && isBuiltinPkg {
+							// Newer go/types reports "type bool bool" alike
+							// declarations as invalid recursive types.
+							if obj := types.Universe.Lookup(typeSpec.Name.Name); obj != nil {
+								tv.Type = obj.Type()
+							}
+						} else if !tv.IsType() {
 							if pkg.Path() != "unsafe" {
 								panic(typeSpec.Name.Name + ": not type")
 							}
@@ -2018,14 +2102,15 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 				panic("should not")
 			case *ast.Ident:
 				id = expr
+			case *ast.IndexExpr, *ast.IndexListExpr:
+				id = genericReceiverBaseTypeIdent(expr)
 			case *ast.StarExpr:
-				tid, ok := expr.X.(*ast.Ident)
-				if !ok {
-					panic("should not")
-				}
-				id = tid
+				id = genericReceiverBaseTypeIdent(expr.X)
 				f.attributes |= StarReceiver
 			}
+			if id == nil {
+				panic("should not")
+			}
 			f.receiverTypeName = d.allTypeNameTable[d.Id2b(pkg, id.Name)]
 			if f.receiverTypeName == nil {
 				panic("should not")
@@ -2268,7 +2353,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectSomeRuntimeFunctionPositions() {
 		for _, f := range fnames {
 			obj := runtimePkg.PPkg.Types.Scope().Lookup(f)
 			if obj == nil {
-				log.Printf("!!! runtime.%s is not found", f)
+				// For example, selectnbrecv2 has been removed since Go 1.17.
+				//log.Printf("!!! runtime.%s is not found", f)
+				continue
 			}
 			d.runtimeFuncPositions[f] = runtimePkg.PPkg.Fset.PositionFor(obj.Pos(), false)
 		}
@@ -2329,6 +2416,9 @@ func (d *CodeAnalyzer) analyzePackage_ConfirmTypeSources(pkg *Package) {
 							//log.Println("   ", pkg.PPkg.TypesInfo.ObjectOf(expr))
 
 							srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr)
+							if srcObj == nil && isBuiltin {
+								srcObj = types.Universe.Lookup(expr.Name)
+							}
 							if srcObj == nil {
 								if pkg.Path() != "unsafe" {
 									panic("srcObj is nil but package is not unsafe")
@@ -2362,6 +2452,15 @@ func (d *CodeAnalyzer) analyzePackage_ConfirmTypeSources(pkg *Package) {
 
 							//log.Println(startSource, "selector,", pkg.Path()+"."+typeSpec.Name.Name, "source is:", tn.Pkg.Path()+"."+expr.Sel.Name)
 							return
+						case *ast.IndexExpr, *ast.IndexListExpr:
+							// An instantiated type. Its source is the generic type.
+							if named, ok := pkg.PPkg.TypesInfo.TypeOf(srcNode).(*types.Named); ok {
+								origin := named.Origin().Obj()
+								if tn := d.allTypeNameTable[d.Id2(origin.Pkg(), origin.Name())]; tn != nil {
+									source.TypeName = tn
+									return
+								}
+							}
 						case *ast.ParenExpr:
 							//log.Println("paren,", pkg.Path()+"."+typeSpec.Name.Name, "source is:")
 							findSource(expr.X, false)
@@ -2424,3 +2523,99 @@ func (d *CodeAnalyzer) analyzePackage_ConfirmTypeSources(pkg *Package) {
 
 	return
 }
+
+// The _test.go files are not loaded by go/packages (Tests is false),
+// so they are parsed here just for collecting examples.
+func (d *CodeAnalyzer) analyzePackage_CollectExamples(pkg *Package) {
+	if pkg.Directory == "" {
+		return
+	}
+
+	filenames, err := filepath.Glob(filepath.Join(pkg.Directory, "*_test.go"))
+	if err != nil || len(filenames) == 0 {
+		return
+	}
+
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(filenames))
+	ctx := build.Default
+	if len(d.platforms) > 0 {
+		ctx.GOOS, ctx.GOARCH = d.platforms[0].GOOS, d.platforms[0].GOARCH
+	}
+	for _, filename := range filenames {
+		if ok, _ := ctx.MatchFile(pkg.Directory, filepath.Base(filename)); !ok {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			log.Printf("parse %s error: %s", filename, err)
+			continue
+		}
+		// Files of both the package and its external test package are used.
+		if name := f.Name.Name; name != pkg.PPkg.Name && name != pkg.PPkg.Name+"_test" {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	for _, ex := range doc.Examples(files...) {
+		example := &Example{Example: ex, Pkg: pkg, Fset: fset}
+		example.Exemplified, _ = splitExampleName(ex.Name)
+		if example.Exemplified == "" {
+			pkg.Examples = append(pkg.Examples, example)
+			continue
+		}
+
+		// Examples for unknown identifiers are ignored, just like go doc does.
+		if i := strings.IndexByte(example.Exemplified, '_'); i >= 0 {
+			typeName, methodName := example.Exemplified[:i], example.Exemplified[i+1:]
+			for _, f := range pkg.AllFunctions {
+				if f.receiverTypeName != nil && f.receiverTypeName.Name() == typeName && f.Name() == methodName {
+					f.Examples = append(f.Examples, example)
+					break
+				}
+			}
+			continue
+		}
+
+		for _, tn := range pkg.AllTypeNames {
+			if tn.Name() == example.Exemplified {
+				tn.Examples = append(tn.Examples, example)
+				goto Next
+			}
+		}
+		for _, f := range pkg.AllFunctions {
+			if f.receiverTypeName == nil && f.Name() == example.Exemplified {
+				f.Examples = append(f.Examples, example)
+				goto Next
+			}
+		}
+	Next:
+	}
+}
+
+// Split an example name into the exemplified identifier part and the suffix part.
+// Same as go/doc, a suffix must start with a lower-case letter.
+func splitExampleName(name string) (exemplified, suffix string) {
+	i := strings.LastIndexByte(name, '_')
+	if r, _ := utf8.DecodeRuneInString(name[i+1:]); i >= 0 && unicode.IsLower(r) {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+// The base type of a method receiver might be instantiated
+// with the receiver type parameters, such as T[K, V].
+func genericReceiverBaseTypeIdent(expr ast.Expr) *ast.Ident {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.IndexExpr:
+		id, _ := e.X.(*ast.Ident)
+		return id
+	case *ast.IndexListExpr:
+		id, _ := e.X.(*ast.Ident)
+		return id
+	}
+	return nil
+}
@@ -1,14 +1,18 @@
 package code
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"go/ast"
 	"go/build"
 	"go/parser"
 	"go/token"
 	"go/types"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -76,7 +80,79 @@ func collectStdPackages() ([]string, error) {
 	return pkgs, nil
 }
 
+// PackagesFingerprint calculates a fingerprint for the packages specified
+// by args and all their dependencies. The fingerprint is decided by the args,
+// the parse options, GOOS/GOARCH, the Go toolchain version and the hashes of
+// all involved source files. The packages are not type-checked in this
+// function, so it is much faster than ParsePackages.
+func PackagesFingerprint(options ParseOptions, args ...string) (string, error) {
+	goVersion, err := util.RunShellCommand(time.Minute, "", nil, "go", "version")
+	if err != nil {
+		return "", fmt.Errorf("get go version error: %s", err)
+	}
+
+	// The files used on all the platforms are involved.
+	var files []string
+	for i := 0; i == 0 || i < len(options.Platforms); i++ {
+		var configForFingerprint = &packages.Config{
+			Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps,
+			Tests: options.Tests,
+		}
+		if len(options.Platforms) > 0 {
+			configForFingerprint.Env = platformEnv(options.Platforms[i])
+		}
+		ppkgs, err := packages.Load(configForFingerprint, args...)
+		if err != nil {
+			return "", err
+		}
+
+		packages.Visit(ppkgs, nil, func(ppkg *packages.Package) {
+			files = append(files, ppkg.GoFiles...)
+			files = append(files, ppkg.OtherFiles...)
+		})
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%q\n%+v\n%s/%s\n%s\n", args, options, build.Default.GOOS, build.Default.GOARCH, goVersion)
+	var last string
+	for _, f := range files {
+		if f == last {
+			continue // test variants and platforms share files
+		}
+		last = f
+
+		content, err := ioutil.ReadFile(f)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(content)
+		fmt.Fprintf(h, "%s %x\n", f, sum)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+type ParseOptions struct {
+	// Whether or not to load test packages, including external test packages.
+	// The test files of a package are merged into the package.
+	Tests bool
+
+	// Return false instead of exiting the program when some packages
+	// have errors. Code might be being edited in watching mode.
+	NoExitOnErrors bool
+
+	// The packages are parsed and analyzed on the first platform.
+	// The declarations on the other ones are merged into the result
+	// (see collectPlatformDeclarations).
+	Platforms []Platform
+}
+
+// ParsePackages parses the packages specified by args with the default options.
 func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...int32), args ...string) bool {
+	return d.ParsePackagesWithOptions(onSubTaskDone, ParseOptions{}, args...)
+}
+
+func (d *CodeAnalyzer) ParsePackagesWithOptions(onSubTaskDone func(int, time.Duration, ...int32), options ParseOptions, args ...string) bool {
 
 	var stopWatch = util.NewStopWatch()
 	if onSubTaskDone == nil {
@@ -100,7 +176,9 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 Start:
 	//log.Println("[parse packages ...], args:", args)
 
-	// ToDo: check cache to avoid parsing again.
+	// The parse and analysis results are not cached, for they reference
+	// many go/types values which are hard to be serialized. Docs server
+	// caches the rendered pages instead. See PackagesFingerprint.
 
 	var numParsedPackages int32
 
@@ -108,10 +186,12 @@ Start:
 		Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps |
 			packages.NeedTypes | packages.NeedExportsFile | packages.NeedFiles |
 			packages.NeedCompiledGoFiles | packages.NeedTypesSizes |
-			packages.NeedSyntax | packages.NeedTypesInfo,
-		Tests: false, // ToDo: parse tests
-		// It looks, if Tests is set to true, then run "GOOS=windows golds std" will fail with
+			packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule,
+		Tests: options.Tests,
+		// It looks, if the test variants are used directly, then run "GOOS=windows golds std" will fail with
 		//		panic: TypeName for runtime.LFNode not found
+		// So the test files are re-checked and merged into the non-test packages now.
+		// See mergeTestPackages for details.
 
 		//Logf: func(format string, args ...interface{}) {
 		//	log.Println("================================================\n", args)
@@ -142,6 +222,9 @@ Start:
 		//       But it looks NeedTypes doesn't consume much more memory, so ...
 		//       And, go/types can be used to verify the correctness of the custom implementaion.
 	}
+	if len(options.Platforms) > 0 {
+		configForParsing.Env = platformEnv(options.Platforms[0])
+	}
 
 	ppkgs, err := packages.Load(configForParsing, args...)
 	if err != nil {
@@ -179,10 +262,22 @@ Start:
 		}
 	}
 	if hasErrors {
+		if options.NoExitOnErrors {
+			log.Println("stop for above errors")
+			return false
+		}
 		log.Fatal("exit for above errors")
 	}
 
+	var testPPkgs []*packages.Package
+	if options.Tests {
+		ppkgs, testPPkgs = splitTestPackages(ppkgs)
+	}
+
 	var allPPkgs = collectPPackages(ppkgs)
+	if len(testPPkgs) > 0 {
+		mergeTestPackages(allPPkgs, testPPkgs)
+	}
 	d.packageList = make([]*Package, 0, len(allPPkgs))
 	d.packageTable = make(map[string]*Package, len(allPPkgs))
 
@@ -252,12 +347,12 @@ Start:
 
 	// Confirm std packages.
 	d.stdModule = &Module{
-		Dir:     "",
-		Root:    "",
+		Dir:     filepath.Join(build.Default.GOROOT, "src"),
+		Root:    "std",
 		Version: "", // ToDo
 	}
 	estimatedNumMods := 1 + len(d.packageList)/3
-	d.allModules = make([]*Module, estimatedNumMods)
+	d.allModules = make([]*Module, 0, estimatedNumMods)
 	d.allModules = append(d.allModules, d.stdModule)
 
 	for _, path := range stdPkgs {
@@ -267,11 +362,279 @@ Start:
 		}
 	}
 
+	d.platforms = options.Platforms
+	if len(options.Platforms) > 1 {
+		d.collectPlatformDeclarations(options, args)
+	}
+
 	// ...
 
 	return true
 }
 
+// isTestVariant reports whether or not a package is loaded for testing.
+// The ID of such a package is either "p [p.test]" or "p.test".
+func isTestVariant(ppkg *packages.Package) bool {
+	return strings.HasSuffix(ppkg.ID, ".test]") || strings.HasSuffix(ppkg.ID, ".test")
+}
+
+func splitTestPackages(ppkgs []*packages.Package) (nonTests, tests []*packages.Package) {
+	nonTests = make([]*packages.Package, 0, len(ppkgs))
+	for _, ppkg := range ppkgs {
+		if isTestVariant(ppkg) {
+			tests = append(tests, ppkg)
+		} else {
+			nonTests = append(nonTests, ppkg)
+		}
+	}
+	return
+}
+
+// mergeTestPackages merges the test files of a package into the package
+// and adds external test packages into allPPkgs. The "p.test" main packages
+// are ignored.
+//
+// The test variants can't be used directly, for the types in them
+// are different from the ones in the non-test packages. And go/types
+// doesn't support checking test files against an already checked package
+// (for example, a method declared in a test file for a non-test type makes
+// it panic). So a package is type-checked again as a whole together with
+// its test files, and so are all the packages depending on it. This makes
+// sure that there are no duplicate packages.
+func mergeTestPackages(allPPkgs map[string]*packages.Package, testPPkgs []*packages.Package) {
+	var internals, externals = make(map[string]*packages.Package, len(testPPkgs)), make([]*packages.Package, 0, len(testPPkgs))
+	for _, tp := range testPPkgs {
+		if strings.HasSuffix(tp.ID, ".test") { // the generated main package
+			continue
+		}
+		if allPPkgs[tp.PkgPath] != nil {
+			internals[tp.PkgPath] = tp
+		} else if strings.HasSuffix(tp.PkgPath, "_test") {
+			externals = append(externals, tp)
+		}
+	}
+
+	// The packages only imported by test files.
+	var regPkgs func(imp *packages.Package) bool
+	regPkgs = func(imp *packages.Package) bool {
+		if allPPkgs[imp.PkgPath] != nil {
+			return true
+		}
+		if isTestVariant(imp) { // a non-test version is needed.
+			return false
+		}
+		allPPkgs[imp.PkgPath] = imp
+		for _, p := range imp.Imports {
+			regPkgs(p)
+		}
+		return true
+	}
+	var regTestImports = func(tp *packages.Package) bool {
+		for _, imp := range tp.Imports {
+			if !regPkgs(imp) {
+				log.Printf("test package %s is ignored, for it depends on test variant %s", tp.ID, imp.ID)
+				return false
+			}
+		}
+		return true
+	}
+
+	// Test files in the same packages must be merged before
+	// handling external test packages, for the latter might
+	// use the declarations in the former.
+	var testFiles = make(map[string][]int, len(internals))
+	for path, tp := range internals {
+		// Cgo is not supported in test files, so the test files
+		// are not processed and could be identified by their names.
+		var indexes []int
+		for i, f := range tp.CompiledGoFiles {
+			if IsTestFile(f) {
+				indexes = append(indexes, i)
+			}
+		}
+		if len(indexes) > 0 && regTestImports(tp) {
+			testFiles[path] = indexes
+		}
+	}
+	if len(testFiles) > 0 {
+		recheckPackagesWithTestFiles(allPPkgs, internals, testFiles)
+	}
+
+	for _, tp := range externals {
+		if !regTestImports(tp) {
+			continue
+		}
+
+		imports := make(map[string]*packages.Package, len(tp.Imports))
+		for path, imp := range tp.Imports {
+			imports[path] = allPPkgs[imp.PkgPath]
+		}
+
+		pkg := types.NewPackage(tp.PkgPath, tp.Name)
+		info := newTypesInfo()
+		if err := checkPackageFiles(tp, pkg, tp.Syntax, info, imports, nil); err != nil {
+			log.Printf("test package %s is ignored, for it fails to type-check: %s", tp.ID, err)
+			continue
+		}
+
+		allPPkgs[tp.PkgPath] = &packages.Package{
+			ID:              tp.PkgPath,
+			Name:            tp.Name,
+			PkgPath:         tp.PkgPath,
+			GoFiles:         tp.GoFiles,
+			CompiledGoFiles: tp.CompiledGoFiles,
+			OtherFiles:      tp.OtherFiles,
+			Imports:         imports,
+			Types:           pkg,
+			Fset:            tp.Fset,
+			Syntax:          tp.Syntax,
+			TypesInfo:       info,
+			TypesSizes:      tp.TypesSizes,
+			Module:          tp.Module,
+		}
+	}
+}
+
+// recheckPackagesWithTestFiles type-checks the packages having test files
+// again together with their test files (specified by indexes in the Syntax
+// fields of the test variants), and all the packages depending on them again.
+// The old results are replaced only if all of the checks succeed.
+func recheckPackagesWithTestFiles(allPPkgs map[string]*packages.Package, internals map[string]*packages.Package, testFiles map[string][]int) {
+	type result struct {
+		pkg     *types.Package
+		info    *types.Info
+		imports map[string]*packages.Package
+		files   []*ast.File
+		names   []string
+	}
+	var results = make(map[string]*result, len(allPPkgs))
+	var visited = make(map[string]bool, len(allPPkgs))
+	var failed bool
+
+	var check func(ppkg *packages.Package)
+	check = func(ppkg *packages.Package) {
+		if visited[ppkg.PkgPath] {
+			return
+		}
+		visited[ppkg.PkgPath] = true
+
+		var r = &result{imports: ppkg.Imports, files: ppkg.Syntax}
+		var changed bool
+		if indexes := testFiles[ppkg.PkgPath]; indexes != nil {
+			tp := internals[ppkg.PkgPath]
+			r.imports = make(map[string]*packages.Package, len(ppkg.Imports)+len(tp.Imports))
+			for path, imp := range ppkg.Imports {
+				r.imports[path] = imp
+			}
+			for path, imp := range tp.Imports {
+				if r.imports[path] == nil {
+					r.imports[path] = allPPkgs[imp.PkgPath]
+				}
+			}
+			r.files = make([]*ast.File, len(ppkg.Syntax), len(ppkg.Syntax)+len(indexes))
+			copy(r.files, ppkg.Syntax)
+			for _, i := range indexes {
+				r.files = append(r.files, tp.Syntax[i])
+				r.names = append(r.names, tp.CompiledGoFiles[i])
+			}
+			changed = true
+		}
+		for _, imp := range r.imports {
+			check(imp)
+			if results[imp.PkgPath] != nil {
+				changed = true
+			}
+		}
+		if !changed || failed {
+			return
+		}
+		if len(ppkg.Syntax) == 0 {
+			log.Printf("test files are not merged, for the syntax of package %s is unavailable", ppkg.PkgPath)
+			failed = true
+			return
+		}
+
+		r.pkg = types.NewPackage(ppkg.PkgPath, ppkg.Name)
+		r.info = newTypesInfo()
+		var rechecked = func(path string) *types.Package {
+			if dep := results[path]; dep != nil {
+				return dep.pkg
+			}
+			return nil
+		}
+		if err := checkPackageFiles(ppkg, r.pkg, r.files, r.info, r.imports, rechecked); err != nil {
+			log.Printf("test files are not merged, for package %s fails to type-check with them: %s", ppkg.PkgPath, err)
+			failed = true
+			return
+		}
+		results[ppkg.PkgPath] = r
+	}
+	for _, ppkg := range allPPkgs {
+		check(ppkg)
+	}
+	if failed {
+		return
+	}
+
+	for path, r := range results {
+		ppkg := allPPkgs[path]
+		ppkg.Types, ppkg.TypesInfo = r.pkg, r.info
+		if len(r.names) > 0 {
+			ppkg.Imports = r.imports
+			ppkg.GoFiles = append(ppkg.GoFiles, r.names...)
+			ppkg.CompiledGoFiles = append(ppkg.CompiledGoFiles, r.names...)
+			ppkg.Syntax = r.files
+		}
+	}
+}
+
+// checkPackageFiles type-checks files as the ones of ppkg. The imported
+// packages are looked up in rechecked firstly if it is not nil.
+func checkPackageFiles(ppkg *packages.Package, pkg *types.Package, files []*ast.File, info *types.Info, imports map[string]*packages.Package, rechecked func(path string) *types.Package) error {
+	var conf = types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == "unsafe" {
+				return types.Unsafe, nil
+			}
+			imp := imports[path]
+			if imp == nil {
+				return nil, fmt.Errorf("package %s is not found", path)
+			}
+			if rechecked != nil {
+				if p := rechecked(imp.PkgPath); p != nil {
+					return p, nil
+				}
+			}
+			return imp.Types, nil
+		}),
+		Sizes: ppkg.TypesSizes,
+		Error: func(err error) {
+			log.Println(err)
+		},
+	}
+	if ppkg.Module != nil && ppkg.Module.GoVersion != "" {
+		conf.GoVersion = "go" + ppkg.Module.GoVersion
+	}
+	return types.NewChecker(&conf, ppkg.Fset, pkg, info).Files(files)
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+func newTypesInfo() *types.Info {
+	return &types.Info{
+		Types:        make(map[ast.Expr]types.TypeAndValue),
+		Instances:    make(map[*ast.Ident]types.Instance),
+		Defs:         make(map[*ast.Ident]types.Object),
+		Uses:         make(map[*ast.Ident]types.Object),
+		Implicits:    make(map[ast.Node]types.Object),
+		Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:       make(map[ast.Node]*types.Scope),
+		FileVersions: make(map[*ast.File]string),
+	}
+}
+
 func fillUnsafePackage(unsafePPkg *packages.Package, builtinPPkg *packages.Package) {
 	intType := builtinPPkg.Types.Scope().Lookup("int").Type()
 
@@ -374,6 +737,11 @@ func fillUnsafePackage(unsafePPkg *packages.Package, builtinPPkg *packages.Packa
 					typeObj = types.NewTypeName(typeSpec.Pos(), types.Unsafe, typeSpec.Name.Name, nil)
 					unsafePPkg.Types.Scope().Insert(typeObj)
 					artitraryType = types.NewNamed(typeObj, intType.Underlying(), nil)
+				case "IntegerType": // since Go 1.17
+					typeObj = types.NewTypeName(typeSpec.Pos(), types.Unsafe, typeSpec.Name.Name, nil)
+					unsafePPkg.Types.Scope().Insert(typeObj)
+					types.NewNamed(typeObj, intType.Underlying(), nil)
+					unsafePPkg.TypesInfo.Types[typeSpec.Type] = types.TypeAndValue{Type: intType}
 				}
 
 				// new declared type (source type will be set below)
@@ -0,0 +1,101 @@
+package code
+
+import (
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// A DeprecatedUse is a use of a deprecated object
+// in a package other than the one declaring it.
+type DeprecatedUse struct {
+	Object types.Object
+	Name   string // named the same way as ObjectUses.Name
+	Identifier
+}
+
+// deprecationNote returns the paragraph starting with "Deprecated: " in a doc,
+// which is the convention to mark a declaration as deprecated.
+func deprecationNote(doc string) string {
+	for _, p := range strings.Split(doc, "\n\n") {
+		if p = strings.TrimSpace(p); strings.HasPrefix(p, "Deprecated: ") {
+			return p
+		}
+	}
+	return ""
+}
+
+// collectDeprecations finds the deprecated objects among the documented ones
+// and the uses of them. It is called after object references and docs are
+// collected. Uses in the declaring packages are ignored.
+func (d *CodeAnalyzer) collectDeprecations() {
+	d.deprecations = make(map[types.Object]string, 256)
+	for obj, res := range d.objectDocs {
+		note := deprecationNote(res.Documentation())
+		if note == "" {
+			note = deprecationNote(res.Comment())
+		}
+		if note != "" {
+			d.deprecations[obj] = note
+		}
+	}
+
+	d.deprecatedUses = make(map[*Package][]DeprecatedUse, 64)
+	var fieldOwners = make(map[*Package]map[*types.Var]string, 64)
+	for obj, ids := range d.objectRefs {
+		obj = originObject(obj)
+		if _, ok := d.deprecations[obj]; !ok {
+			continue
+		}
+		var name string
+		for _, id := range ids {
+			if id.FileInfo.Pkg.Path() == obj.Pkg().Path() {
+				continue
+			}
+			if name == "" {
+				name = d.objectUsesName(d.packageTable[obj.Pkg().Path()], obj, fieldOwners)
+			}
+			d.deprecatedUses[id.FileInfo.Pkg] = append(d.deprecatedUses[id.FileInfo.Pkg], DeprecatedUse{obj, name, id})
+		}
+	}
+	for _, uses := range d.deprecatedUses {
+		sort.Slice(uses, func(i, j int) bool {
+			return uses[i].AstIdent.Pos() < uses[j].AstIdent.Pos()
+		})
+	}
+}
+
+// Uses of instantiated methods and fields are viewed as
+// the uses of their generic versions.
+func originObject(obj types.Object) types.Object {
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.Origin()
+	case *types.Var:
+		if o.IsField() {
+			return o.Origin()
+		}
+	}
+	return obj
+}
+
+// ObjectDeprecation returns the "Deprecated: " paragraph in the docs of
+// a package-level declared object, or a field or method of a package-level
+// declared type. Blank is returned if the object is not deprecated.
+func (d *CodeAnalyzer) ObjectDeprecation(obj types.Object) string {
+	if obj == nil {
+		return ""
+	}
+	return d.deprecations[originObject(obj)]
+}
+
+// ResourceDeprecation is like ObjectDeprecation, but for package-level resources.
+func (d *CodeAnalyzer) ResourceDeprecation(res Resource) string {
+	return d.ObjectDeprecation(resourceObject(res))
+}
+
+// DeprecatedUses returns the uses of deprecated objects in pkg,
+// sorted by positions. Deprecated objects declared in pkg are excluded.
+func (d *CodeAnalyzer) DeprecatedUses(pkg *Package) []DeprecatedUse {
+	return d.deprecatedUses[pkg]
+}
@@ -0,0 +1,160 @@
+package code
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// findGenericInterfaceImplementations finds the types implementing some
+// instantiations of generic interface types. The implementation-finding
+// algorithm in analyzePackages_FindImplementations compares method
+// signatures, which doesn't work for generic interface types, for their
+// method signatures contain type parameters.
+//
+// The type arguments of such an instantiation are inferred by unifying
+// the method signatures of a generic interface type with the ones of
+// a type. The type is viewed as an implementation of the generic
+// interface type if it implements the instantiated interface type.
+func (d *CodeAnalyzer) findGenericInterfaceImplementations() {
+	var generics, values, pointers []*TypeInfo
+	for _, t := range d.allTypeInfos {
+		switch tt := t.TT.(type) {
+		case *types.Named:
+			itt, ok := tt.Underlying().(*types.Interface)
+			if !ok {
+				values = append(values, t)
+			} else if tt.TypeParams().Len() > 0 && tt.TypeArgs().Len() == 0 && itt.NumMethods() > 0 {
+				generics = append(generics, t)
+			}
+		case *types.Pointer:
+			if named, ok := tt.Elem().(*types.Named); ok {
+				if _, ok := named.Underlying().(*types.Interface); !ok {
+					pointers = append(pointers, t)
+				}
+			}
+		}
+	}
+	if len(generics) == 0 {
+		return
+	}
+
+	var methodSets typeutil.MethodSetCache
+	for _, it := range generics {
+		named := it.TT.(*types.Named)
+
+		// Same as analyzePackages_FindImplementations, a pointer type
+		// is listed as an implementation only if its base type is not.
+		var implementedBys = it.ImplementedBys[:len(it.ImplementedBys):len(it.ImplementedBys)]
+		var valueImplements = make(map[types.Type]bool)
+		for _, t := range values {
+			if implementsGenericInterface(methodSets.MethodSet(t.TT), t.TT, named) {
+				t.Implements = append(t.Implements, Implementation{Impler: t, Interface: it})
+				implementedBys = append(implementedBys, t)
+				valueImplements[t.TT] = true
+			}
+		}
+		for _, t := range pointers {
+			if implementsGenericInterface(methodSets.MethodSet(t.TT), t.TT, named) {
+				t.Implements = append(t.Implements, Implementation{Impler: t, Interface: it})
+				if !valueImplements[t.TT.(*types.Pointer).Elem()] {
+					implementedBys = append(implementedBys, t)
+				}
+			}
+		}
+		it.ImplementedBys = implementedBys
+	}
+}
+
+// implementsGenericInterface reports whether or not tt (whose method set
+// is ms) implements an instantiation of the generic interface type.
+func implementsGenericInterface(ms *types.MethodSet, tt types.Type, generic *types.Named) bool {
+	itt := generic.Underlying().(*types.Interface)
+	var bindings = make(map[*types.TypeParam]types.Type, generic.TypeParams().Len())
+	for i := 0; i < itt.NumMethods(); i++ {
+		m := itt.Method(i)
+		sel := ms.Lookup(m.Pkg(), m.Name())
+		if sel == nil {
+			return false
+		}
+		if !unifyTypes(m.Type(), sel.Type(), bindings) {
+			return false
+		}
+	}
+
+	targs := make([]types.Type, generic.TypeParams().Len())
+	for i := range targs {
+		if targs[i] = bindings[generic.TypeParams().At(i)]; targs[i] == nil {
+			return false // not inferable from the methods
+		}
+	}
+	inst, err := types.Instantiate(nil, generic, targs, true)
+	if err != nil {
+		return false // constraints not satisfied
+	}
+	return types.Implements(tt, inst.Underlying().(*types.Interface))
+}
+
+// unifyTypes reports whether or not x, which might contain type parameters,
+// could be identical to y. The type parameters are bound to the corresponding
+// types in y.
+func unifyTypes(x, y types.Type, bindings map[*types.TypeParam]types.Type) bool {
+	x, y = types.Unalias(x), types.Unalias(y)
+	switch x := x.(type) {
+	case *types.TypeParam:
+		if b, ok := bindings[x]; ok {
+			return types.Identical(b, y)
+		}
+		bindings[x] = y
+		return true
+	case *types.Pointer:
+		y, ok := y.(*types.Pointer)
+		return ok && unifyTypes(x.Elem(), y.Elem(), bindings)
+	case *types.Slice:
+		y, ok := y.(*types.Slice)
+		return ok && unifyTypes(x.Elem(), y.Elem(), bindings)
+	case *types.Array:
+		y, ok := y.(*types.Array)
+		return ok && x.Len() == y.Len() && unifyTypes(x.Elem(), y.Elem(), bindings)
+	case *types.Map:
+		y, ok := y.(*types.Map)
+		return ok && unifyTypes(x.Key(), y.Key(), bindings) && unifyTypes(x.Elem(), y.Elem(), bindings)
+	case *types.Chan:
+		y, ok := y.(*types.Chan)
+		return ok && x.Dir() == y.Dir() && unifyTypes(x.Elem(), y.Elem(), bindings)
+	case *types.Signature:
+		y, ok := y.(*types.Signature)
+		return ok && x.Variadic() == y.Variadic() &&
+			unifyTuples(x.Params(), y.Params(), bindings) &&
+			unifyTuples(x.Results(), y.Results(), bindings)
+	case *types.Named:
+		y, ok := y.(*types.Named)
+		if !ok || x.Origin() != y.Origin() {
+			return false
+		}
+		xargs, yargs := x.TypeArgs(), y.TypeArgs()
+		if xargs.Len() != yargs.Len() {
+			return false
+		}
+		for i := 0; i < xargs.Len(); i++ {
+			if !unifyTypes(xargs.At(i), yargs.At(i), bindings) {
+				return false
+			}
+		}
+		return true
+	}
+	// Type parameters in struct and interface type literals are not supported.
+	return types.Identical(x, y)
+}
+
+func unifyTuples(x, y *types.Tuple, bindings map[*types.TypeParam]types.Type) bool {
+	if x.Len() != y.Len() {
+		return false
+	}
+	for i := 0; i < x.Len(); i++ {
+		if !unifyTypes(x.At(i).Type(), y.At(i).Type(), bindings) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package code
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// An ImportRule allows or forbids the packages matching the Importer
+// pattern to import the packages matching the Imported pattern.
+type ImportRule struct {
+	Allow    bool
+	Importer string
+	Imported string
+	Line     int // the line number in the rules file
+
+	importerRegexp *regexp.Regexp
+	importedRegexp *regexp.Regexp
+}
+
+func (r ImportRule) String() string {
+	verb := "forbid"
+	if r.Allow {
+		verb = "allow"
+	}
+	return verb + " " + r.Importer + " " + r.Imported
+}
+
+// An ImportViolation is an import forbidden by an import rule.
+type ImportViolation struct {
+	Importer *Package
+	Imported *Package
+	Rule     ImportRule
+}
+
+// ParseImportRules parses an import rules file. Each line in it is
+// blank, a comment (starting with "#"), or a rule in the form of
+//
+//	allow|forbid <ImporterPattern> <ImportedPattern>
+//
+// Like the go command, "..." in a pattern matches any string,
+// and a pattern "x/..." also matches x itself. The "std" pattern
+// matches all standard packages.
+func ParseImportRules(r io.Reader) ([]ImportRule, error) {
+	var rules []ImportRule
+	var scanner = bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		tokens := strings.Fields(text)
+		if len(tokens) != 3 {
+			return nil, fmt.Errorf("line %d: a rule should be like \"allow|forbid <ImporterPattern> <ImportedPattern>\"", line)
+		}
+		rule := ImportRule{Importer: tokens[1], Imported: tokens[2], Line: line}
+		switch tokens[0] {
+		default:
+			return nil, fmt.Errorf("line %d: unknown rule verb: %s", line, tokens[0])
+		case "allow":
+			rule.Allow = true
+		case "forbid":
+		}
+		rule.importerRegexp = importPathPatternRegexp(rule.Importer)
+		rule.importedRegexp = importPathPatternRegexp(rule.Imported)
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Nil is returned for the "std" pattern.
+func importPathPatternRegexp(pattern string) *regexp.Regexp {
+	if pattern == "std" {
+		return nil
+	}
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = re[:len(re)-len(`/.*`)] + `(/.*)?`
+	}
+	return regexp.MustCompile(`^` + re + `$`)
+}
+
+func (d *CodeAnalyzer) matchImportPathPattern(re *regexp.Regexp, pkg *Package) bool {
+	if re == nil {
+		return d.IsStandardPackage(pkg)
+	}
+	return re.MatchString(pkg.Path())
+}
+
+// CheckImportRules checks the direct imports of all packages against
+// the rules. For each import, the last matching rule decides whether
+// or not it is allowed. Imports matching no rules are allowed.
+// The violations are sorted by importer paths then imported paths.
+func (d *CodeAnalyzer) CheckImportRules(rules []ImportRule) []ImportViolation {
+	var violations []ImportViolation
+	for _, pkg := range d.packageList {
+		pkg.importViolations = nil
+		for _, dep := range pkg.Deps {
+			for i := len(rules) - 1; i >= 0; i-- {
+				rule := rules[i]
+				if d.matchImportPathPattern(rule.importerRegexp, pkg) && d.matchImportPathPattern(rule.importedRegexp, dep) {
+					if !rule.Allow {
+						pkg.importViolations = append(pkg.importViolations, ImportViolation{Importer: pkg, Imported: dep, Rule: rule})
+					}
+					break
+				}
+			}
+		}
+		sort.Slice(pkg.importViolations, func(i, j int) bool {
+			return pkg.importViolations[i].Imported.Path() < pkg.importViolations[j].Imported.Path()
+		})
+		violations = append(violations, pkg.importViolations...)
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Importer.Path() < violations[j].Importer.Path()
+	})
+	d.importViolations = violations
+	d.importRulesChecked = true
+	return violations
+}
+
+// ImportRulesChecked returns whether or not import rules have been checked.
+func (d *CodeAnalyzer) ImportRulesChecked() bool {
+	return d.importRulesChecked
+}
+
+// ImportViolations returns all the import rule violations.
+func (d *CodeAnalyzer) ImportViolations() []ImportViolation {
+	return d.importViolations
+}
+
+// PackageImportViolations returns the import rule violations of pkg.
+func (d *CodeAnalyzer) PackageImportViolations(pkg *Package) []ImportViolation {
+	return pkg.importViolations
+}
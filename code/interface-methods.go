@@ -0,0 +1,87 @@
+package code
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// An InterfaceMethodDeclaration is a specification of a method in the
+// declaration of an interface type, either direct or obtained by embedding.
+type InterfaceMethodDeclaration struct {
+	AstField *ast.Field
+	// The named interface type directly embedded in the declaration
+	// through which the method is obtained. Nil for direct ones.
+	// For example, for an interface type embedding io.ReadCloser,
+	// it is io.ReadCloser for the Read method, instead of io.Reader.
+	Interface *TypeName
+}
+
+func (decl *InterfaceMethodDeclaration) Documentation() string {
+	if doc := decl.AstField.Doc; doc != nil {
+		return doc.Text()
+	}
+	return ""
+}
+
+func (decl *InterfaceMethodDeclaration) Comment() string {
+	if comment := decl.AstField.Comment; comment != nil {
+		return comment.Text()
+	}
+	return ""
+}
+
+// InterfaceMethodDeclarations returns all the specifications of a method of
+// the interface type denoted by tn. There might be several ones when some
+// embedded interface types specify the same method. Their docs might differ.
+//
+// Identical interface types share the same TypeInfo (so the same Method),
+// so the specifications are looked for in the AST declarations instead.
+func (d *CodeAnalyzer) InterfaceMethodDeclarations(tn *TypeName, sel *Selector) []InterfaceMethodDeclaration {
+	if tn.Alias != nil && tn.Denoting().TypeName != nil {
+		tn = tn.Denoting().TypeName
+	}
+	if tn.AstSpec == nil || sel.Method == nil {
+		return nil
+	}
+
+	var decls []InterfaceMethodDeclaration
+	var visited = map[*TypeName]bool{tn: true}
+	var collect func(expr ast.Expr, pkg *Package, from *TypeName)
+	collect = func(expr ast.Expr, pkg *Package, from *TypeName) {
+		for {
+			paren, ok := expr.(*ast.ParenExpr)
+			if !ok {
+				break
+			}
+			expr = paren.X
+		}
+
+		if it, ok := expr.(*ast.InterfaceType); ok {
+			for _, field := range it.Methods.List {
+				if len(field.Names) == 0 { // embedded
+					collect(field.Type, pkg, from)
+				} else if d.Id1b(pkg, field.Names[0].Name) == sel.Id {
+					decls = append(decls, InterfaceMethodDeclaration{AstField: field, Interface: from})
+				}
+			}
+			return
+		}
+
+		named, ok := types.Unalias(pkg.PPkg.TypesInfo.TypeOf(expr)).(*types.Named)
+		if !ok || !types.IsInterface(named) {
+			return // type set terms
+		}
+		named = named.Origin()
+		etn := d.allTypeNameTable[d.Id2(named.Obj().Pkg(), named.Obj().Name())]
+		if etn == nil || etn.AstSpec == nil || visited[etn] {
+			return
+		}
+		visited[etn] = true
+		if from == nil {
+			from = etn
+		}
+		collect(etn.AstSpec.Type, etn.Pkg, from)
+	}
+	collect(tn.AstSpec.Type, tn.Pkg, nil)
+	return decls
+}
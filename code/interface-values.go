@@ -0,0 +1,255 @@
+package code
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// collectInterfaceValueSources records the types of the values which are
+// statically assigned to interface values, either by implicit conversions
+// (in assignments, variable declarations, function calls, return statements,
+// composite literals and channel sends) or by explicit conversions. Values
+// of interface types might also flow to other interface types through type
+// assertions, which are also recorded.
+// ToDo: values flowing through reflection and unsafe ways are not tracked.
+func (d *CodeAnalyzer) collectInterfaceValueSources() {
+	d.interfaceValueSources = make(map[*TypeInfo]map[*TypeInfo]struct{}, 1024)
+	d.interfaceValueTypes = make(map[*TypeInfo]map[*TypeInfo]struct{}, 256)
+
+	var record = func(target, source types.Type) {
+		if target == nil || source == nil {
+			return
+		}
+		if _, ok := target.(*types.TypeParam); ok || !types.IsInterface(target) {
+			return
+		}
+		switch s := source.(type) {
+		case *types.TypeParam, *types.Tuple:
+			return
+		case *types.Basic:
+			if s.Kind() == types.UntypedNil {
+				return
+			}
+		}
+		itype := d.TryRegisteringType(target, false)
+		if itype == nil {
+			return
+		}
+		sources := d.interfaceValueSources[itype]
+		if sources == nil {
+			sources = make(map[*TypeInfo]struct{})
+			d.interfaceValueSources[itype] = sources
+		}
+		if t := d.TryRegisteringType(source, false); t != nil && t != itype {
+			sources[t] = struct{}{}
+		}
+		// Only one of T and *T is listed in the implementers of an
+		// interface type, so the base types of pointers are also recorded.
+		if ptr, ok := types.Unalias(source).(*types.Pointer); ok {
+			if t := d.TryRegisteringType(ptr.Elem(), false); t != nil {
+				sources[t] = struct{}{}
+			}
+		}
+	}
+
+	for _, pkg := range d.packageList {
+		info := pkg.PPkg.TypesInfo
+
+		// The sources of the values of a multi-value expression.
+		var recordTuple = func(targets []types.Type, values []ast.Expr) {
+			if len(values) == len(targets) {
+				for i, v := range values {
+					record(targets[i], info.TypeOf(v))
+				}
+			} else if len(values) == 1 {
+				if tuple, ok := info.TypeOf(values[0]).(*types.Tuple); ok && tuple.Len() == len(targets) {
+					for i := range targets {
+						record(targets[i], tuple.At(i).Type())
+					}
+				}
+			}
+		}
+
+		var walk func(node ast.Node, sig *types.Signature)
+		walk = func(node ast.Node, sig *types.Signature) {
+			ast.Inspect(node, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.FuncDecl:
+					if n.Body == nil {
+						return false
+					}
+					if f, ok := info.Defs[n.Name].(*types.Func); ok {
+						walk(n.Body, f.Type().(*types.Signature))
+					}
+					return false
+				case *ast.FuncLit:
+					if s, ok := info.TypeOf(n).Underlying().(*types.Signature); ok {
+						walk(n.Body, s)
+					}
+					return false
+				case *ast.AssignStmt:
+					var targets = make([]types.Type, len(n.Lhs))
+					for i, lhs := range n.Lhs {
+						targets[i] = info.TypeOf(lhs)
+					}
+					recordTuple(targets, n.Rhs)
+				case *ast.ValueSpec:
+					var targets = make([]types.Type, len(n.Names))
+					for i, name := range n.Names {
+						if obj := info.Defs[name]; obj != nil {
+							targets[i] = obj.Type()
+						}
+					}
+					recordTuple(targets, n.Values)
+				case *ast.ReturnStmt:
+					if sig == nil || sig.Results().Len() == 0 {
+						break
+					}
+					var targets = make([]types.Type, sig.Results().Len())
+					for i := range targets {
+						targets[i] = sig.Results().At(i).Type()
+					}
+					recordTuple(targets, n.Results)
+				case *ast.SendStmt:
+					if ch, ok := info.TypeOf(n.Chan).Underlying().(*types.Chan); ok {
+						record(ch.Elem(), info.TypeOf(n.Value))
+					}
+				case *ast.CallExpr:
+					if tv, ok := info.Types[n.Fun]; ok && tv.IsType() { // conversions
+						if len(n.Args) == 1 {
+							record(tv.Type, info.TypeOf(n.Args[0]))
+						}
+						break
+					}
+					ft := info.TypeOf(n.Fun)
+					if ft == nil {
+						break
+					}
+					fsig, ok := ft.Underlying().(*types.Signature)
+					if !ok {
+						break
+					}
+					params := fsig.Params()
+					var targets = make([]types.Type, len(n.Args))
+					if len(n.Args) == 1 && params.Len() != 1 {
+						targets = make([]types.Type, params.Len()) // f(g())
+					}
+					for i := range targets {
+						switch {
+						case !fsig.Variadic() || i < params.Len()-1:
+							if i < params.Len() {
+								targets[i] = params.At(i).Type()
+							}
+						case n.Ellipsis.IsValid(): // f(a, s...)
+						default:
+							if s, ok := params.At(params.Len() - 1).Type().Underlying().(*types.Slice); ok {
+								targets[i] = s.Elem()
+							}
+						}
+					}
+					recordTuple(targets, n.Args)
+				case *ast.CompositeLit:
+					t := info.TypeOf(n)
+					if t == nil {
+						break
+					}
+					if ptr, ok := t.Underlying().(*types.Pointer); ok { // elided &T{...}
+						t = ptr.Elem()
+					}
+					switch ct := t.Underlying().(type) {
+					case *types.Struct:
+						for i, elt := range n.Elts {
+							if kv, ok := elt.(*ast.KeyValueExpr); ok {
+								if key, ok := kv.Key.(*ast.Ident); ok {
+									if field := info.ObjectOf(key); field != nil {
+										record(field.Type(), info.TypeOf(kv.Value))
+									}
+								}
+							} else if i < ct.NumFields() {
+								record(ct.Field(i).Type(), info.TypeOf(elt))
+							}
+						}
+					case *types.Array, *types.Slice, *types.Map:
+						var keyType, elemType types.Type
+						switch ct := ct.(type) {
+						case *types.Array:
+							elemType = ct.Elem()
+						case *types.Slice:
+							elemType = ct.Elem()
+						case *types.Map:
+							keyType, elemType = ct.Key(), ct.Elem()
+						}
+						for _, elt := range n.Elts {
+							if kv, ok := elt.(*ast.KeyValueExpr); ok {
+								if keyType != nil {
+									record(keyType, info.TypeOf(kv.Key))
+								}
+								elt = kv.Value
+							}
+							record(elemType, info.TypeOf(elt))
+						}
+					}
+				case *ast.TypeAssertExpr:
+					if n.Type != nil {
+						record(info.TypeOf(n.Type), info.TypeOf(n.X))
+					}
+				case *ast.TypeSwitchStmt:
+					var assert *ast.TypeAssertExpr
+					switch s := n.Assign.(type) {
+					case *ast.ExprStmt:
+						assert, _ = s.X.(*ast.TypeAssertExpr)
+					case *ast.AssignStmt:
+						if len(s.Rhs) == 1 {
+							assert, _ = s.Rhs[0].(*ast.TypeAssertExpr)
+						}
+					}
+					if assert == nil {
+						break
+					}
+					for _, stmt := range n.Body.List {
+						for _, e := range stmt.(*ast.CaseClause).List {
+							record(info.TypeOf(e), info.TypeOf(assert.X))
+						}
+					}
+				}
+				return true
+			})
+		}
+
+		for i := range pkg.SourceFiles {
+			if f := pkg.SourceFiles[i].AstFile; f != nil {
+				walk(f, nil)
+			}
+		}
+	}
+}
+
+// InterfaceValueMightHaveType returns whether or not the values of a
+// non-interface type t might be (statically) assigned to the values of
+// interface type itype, directly or through the values of other interface
+// types, in the analyzed packages.
+func (d *CodeAnalyzer) InterfaceValueMightHaveType(itype, t *TypeInfo) bool {
+	valueTypes, ok := d.interfaceValueTypes[itype]
+	if !ok {
+		valueTypes = make(map[*TypeInfo]struct{})
+		var visited = make(map[*TypeInfo]bool)
+		var collect func(it *TypeInfo)
+		collect = func(it *TypeInfo) {
+			if visited[it] {
+				return
+			}
+			visited[it] = true
+			for s := range d.interfaceValueSources[it] {
+				if types.IsInterface(s.TT) {
+					collect(s)
+				} else {
+					valueTypes[s] = struct{}{}
+				}
+			}
+		}
+		collect(itype)
+		d.interfaceValueTypes[itype] = valueTypes
+	}
+	_, has := valueTypes[t]
+	return has
+}
@@ -0,0 +1,81 @@
+package code
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+type documented interface {
+	Documentation() string
+	Comment() string
+}
+
+func (d *CodeAnalyzer) collectObjectDocumentations() {
+	d.objectDocs = make(map[types.Object]documented, 8192)
+	for _, pkg := range d.packageList {
+		for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
+			d.objectDocs[tn.TypeName] = tn
+			if tn.Alias != nil {
+				continue
+			}
+			// The fields are registered on the underlying struct types, which
+			// might be shared by several named types, so they are found in AST.
+			if st, ok := tn.AstSpec.Type.(*ast.StructType); ok {
+				for _, field := range st.Fields.List {
+					fld := &Field{AstField: field, Pkg: pkg}
+					for _, name := range field.Names {
+						if obj := pkg.PPkg.TypesInfo.Defs[name]; obj != nil {
+							d.objectDocs[obj] = fld
+						}
+					}
+					if len(field.Names) == 0 {
+						if obj := (&Selector{Field: fld}).Object(); obj != nil {
+							d.objectDocs[obj] = fld
+						}
+					}
+				}
+			}
+			for _, sel := range tn.Named.DirectSelectors {
+				if sel.Field != nil && sel.Field.AstField == nil {
+					continue // fields of instantiated types
+				}
+				if sel.Method != nil && sel.Method.AstFunc == nil && sel.Method.AstField == nil {
+					continue // methods of instantiated interfaces
+				}
+				if obj := sel.Object(); obj != nil {
+					if sel.Field != nil {
+						d.objectDocs[obj] = sel.Field
+					} else {
+						d.objectDocs[obj] = sel.Method
+					}
+				}
+			}
+		}
+		for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
+			if f.Func != nil {
+				d.objectDocs[f.Func] = f
+			}
+		}
+		for _, v := range pkg.PackageAnalyzeResult.AllVariables {
+			d.objectDocs[v.Var] = v
+		}
+		for _, c := range pkg.PackageAnalyzeResult.AllConstants {
+			d.objectDocs[c.Const] = c
+		}
+	}
+}
+
+// ObjectDocumentation returns the documentation of a package-level declared
+// object, or a field or method of a package-level declared type. If the
+// documentation is blank, the line comment of the object is returned.
+// Blank is returned for other objects.
+func (d *CodeAnalyzer) ObjectDocumentation(obj types.Object) string {
+	res := d.objectDocs[obj]
+	if res == nil {
+		return ""
+	}
+	if doc := res.Documentation(); doc != "" {
+		return doc
+	}
+	return res.Comment()
+}
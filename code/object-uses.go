@@ -0,0 +1,217 @@
+package code
+
+import (
+	"go/types"
+	"sort"
+)
+
+// An ObjectUses records the uses of an exported object in a package.
+type ObjectUses struct {
+	Object types.Object
+	// The object name. For fields and methods, it is prefixed
+	// with the name of the type owning them, such as "Type.Sel".
+	// Fields of the unnamed struct types of package-level variables are
+	// prefixed with the variable names. Fields of other unnamed struct
+	// types are only denoted by their names.
+	Name        string
+	Identifiers []Identifier // file by file, by positions in source
+}
+
+// An ObjectUseStat counts the uses of an exported object in the packages
+// other than the one declaring it.
+type ObjectUseStat struct {
+	Packages int // the number of the using packages
+	Uses     int
+}
+
+// collectExportedObjectUses groups the references of the exported objects
+// by the packages declaring and using them. It is called after object
+// references are collected. Uses in the declaring packages are ignored.
+func (d *CodeAnalyzer) collectExportedObjectUses() {
+	type key struct {
+		user *Package
+		obj  types.Object
+	}
+	var uses = make(map[key]*ObjectUses, 1024)
+	var fieldOwners = make(map[*Package]map[*types.Var]string, 64)
+	var owningPackages = make(map[*Package]struct{}, len(d.packageList))
+
+	for obj, ids := range d.objectRefs {
+		if obj.Pkg() == nil || !obj.Exported() {
+			continue
+		}
+		obj = originObject(obj)
+		switch obj.(type) {
+		case *types.Func, *types.Var, *types.TypeName, *types.Const, *types.Builtin:
+		default:
+			continue
+		}
+		pkg := d.packageTable[obj.Pkg().Path()]
+		if pkg == nil {
+			continue
+		}
+
+		for _, id := range ids {
+			if id.FileInfo.Pkg == pkg {
+				continue
+			}
+			k := key{id.FileInfo.Pkg, obj}
+			ou := uses[k]
+			if ou == nil {
+				ou = &ObjectUses{Object: obj, Name: d.objectUsesName(pkg, obj, fieldOwners)}
+				uses[k] = ou
+				owningPackages[pkg] = struct{}{}
+			}
+			ou.Identifiers = append(ou.Identifiers, id)
+		}
+	}
+
+	for pkg := range owningPackages {
+		pkg.exportedObjectUses = make(map[*Package][]ObjectUses, len(pkg.DepedBys))
+	}
+	d.objectUseStats = make(map[types.Object]ObjectUseStat, len(uses))
+	for k, ou := range uses {
+		stat := d.objectUseStats[k.obj]
+		stat.Packages++
+		stat.Uses += len(ou.Identifiers)
+		d.objectUseStats[k.obj] = stat
+
+		// Several instantiated objects might share one generic object.
+		sort.Slice(ou.Identifiers, func(i, j int) bool {
+			return ou.Identifiers[i].AstIdent.Pos() < ou.Identifiers[j].AstIdent.Pos()
+		})
+		pkg := d.packageTable[k.obj.Pkg().Path()]
+		pkg.exportedObjectUses[k.user] = append(pkg.exportedObjectUses[k.user], *ou)
+	}
+	for pkg := range owningPackages {
+		for _, objUses := range pkg.exportedObjectUses {
+			sort.Slice(objUses, func(i, j int) bool {
+				return objUses[i].Name < objUses[j].Name
+			})
+		}
+	}
+}
+
+func (d *CodeAnalyzer) objectUsesName(pkg *Package, obj types.Object, fieldOwners map[*Package]map[*types.Var]string) string {
+	switch o := obj.(type) {
+	case *types.Func:
+		sig, ok := o.Type().(*types.Signature)
+		if !ok || sig.Recv() == nil {
+			break
+		}
+		recvType := sig.Recv().Type()
+		if ptr, ok := recvType.(*types.Pointer); ok {
+			recvType = ptr.Elem()
+		}
+		if named, ok := recvType.(*types.Named); ok {
+			return named.Obj().Name() + "." + o.Name()
+		}
+	case *types.Var:
+		if !o.IsField() {
+			break
+		}
+		owners := fieldOwners[pkg]
+		if owners == nil {
+			// Only the direct fields of the declared struct types and of the
+			// unnamed struct types of package-level variables are recorded.
+			// The variable names are used as fake type names.
+			owners = make(map[*types.Var]string, 64)
+			scope := pkg.PPkg.Types.Scope()
+			for _, name := range scope.Names() {
+				var st *types.Struct
+				switch o := scope.Lookup(name).(type) {
+				case *types.TypeName:
+					if o.IsAlias() {
+						continue
+					}
+					st, _ = o.Type().Underlying().(*types.Struct)
+				case *types.Var:
+					st, _ = types.Unalias(o.Type()).(*types.Struct)
+				}
+				if st == nil {
+					continue
+				}
+				for i := 0; i < st.NumFields(); i++ {
+					if _, ok := owners[st.Field(i)]; !ok {
+						owners[st.Field(i)] = name
+					}
+				}
+			}
+			fieldOwners[pkg] = owners
+		}
+		if owner, ok := owners[o]; ok {
+			return owner + "." + o.Name()
+		}
+	}
+	return obj.Name()
+}
+
+// ExportedObjectUses returns the uses of the exported objects (including
+// fields and methods) of pkg, grouped by the using packages. The uses in
+// pkg itself are not included. The uses of each package are sorted by
+// object names.
+func (d *CodeAnalyzer) ExportedObjectUses(pkg *Package) map[*Package][]ObjectUses {
+	return pkg.exportedObjectUses
+}
+
+// ResourceUseStat returns the use stat of a package-level resource.
+func (d *CodeAnalyzer) ResourceUseStat(res Resource) ObjectUseStat {
+	obj := resourceObject(res)
+	if obj == nil {
+		return ObjectUseStat{}
+	}
+	return d.objectUseStats[obj]
+}
+
+func resourceObject(res Resource) types.Object {
+	switch res := res.(type) {
+	case *TypeName:
+		return res.TypeName
+	case *Function:
+		if res.Func != nil {
+			return res.Func
+		}
+		return res.Builtin
+	case *Variable:
+		return res.Var
+	case *Constant:
+		return res.Const
+	}
+	return nil
+}
+
+// UnusedExportedResources returns the exported package-level resources of pkg
+// which are not used in other packages, sorted by names. Methods and fields
+// are not checked, for they might be used implicitly through interfaces
+// and embeddings. Test-only resources are also excluded. Nil is returned
+// for main packages and external test packages, which can't be imported.
+func (d *CodeAnalyzer) UnusedExportedResources(pkg *Package) []Resource {
+	if pkg.PPkg.Name == "main" || pkg.IsExternalTest() {
+		return nil
+	}
+
+	var unuseds []Resource
+	var check = func(res Resource, testOnly bool) {
+		if res.Exported() && !testOnly && d.ResourceUseStat(res).Packages == 0 {
+			unuseds = append(unuseds, res)
+		}
+	}
+	for _, tn := range pkg.AllTypeNames {
+		check(tn, tn.TestOnly())
+	}
+	for _, f := range pkg.AllFunctions {
+		if !f.IsMethod() {
+			check(f, f.TestOnly())
+		}
+	}
+	for _, v := range pkg.AllVariables {
+		check(v, v.TestOnly())
+	}
+	for _, c := range pkg.AllConstants {
+		check(c, c.TestOnly())
+	}
+	sort.Slice(unuseds, func(i, j int) bool {
+		return unuseds[i].Name() < unuseds[j].Name()
+	})
+	return unuseds
+}
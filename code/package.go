@@ -2,6 +2,7 @@ package code
 
 import (
 	"go/ast"
+	"go/doc"
 	"go/token"
 	"go/types"
 	"log"
@@ -15,6 +16,21 @@ type Module struct {
 	Dir     string
 	Root    string // root import path
 	Version string
+
+	// Non-nil if the module is replaced. Only Root, Version
+	// and Dir are set for the replacement module.
+	Replace *Module
+
+	// The go.mod file used when loading the module. Might be blank.
+	GoMod string
+
+	// Sorted by package path.
+	Pkgs []*Package
+
+	// Only the modules involved in the analysis are listed.
+	// Both are sorted by module path.
+	Requires    []*Module
+	RequiredBys []*Module
 }
 
 type Package struct {
@@ -29,12 +45,38 @@ type Package struct {
 	// This field might be shared with PackageForDisplay
 	// for concurrent reads.
 	*PackageAnalyzeResult
+
+	// Declaration identity to platform bits. Only set when
+	// docs for several platforms are merged.
+	platformDecls map[string]uint64
+
+	// Docs and source code of the declarations which
+	// don't exist on the primary platform.
+	platformDeclTexts map[string]platformDeclarationText
+
+	// Uses of the exported objects, grouped by the using packages.
+	exportedObjectUses map[*Package][]ObjectUses
+
+	// Imports of this package forbidden by import rules.
+	importViolations []ImportViolation
 }
 
 func (p *Package) Path() string {
 	return p.PPkg.PkgPath // might be prefixed with "vendor/", which is different from import path.
 }
 
+// External test packages are only loaded in the tests mode.
+func (p *Package) IsExternalTest() bool {
+	return strings.HasSuffix(p.PPkg.Name, "_test") &&
+		len(p.PPkg.GoFiles) > 0 && IsTestFile(p.PPkg.GoFiles[0])
+}
+
+// IsTestFile reports whether or not the file is a _test.go file.
+// Declarations in such files are test-only declarations.
+func IsTestFile(filename string) bool {
+	return strings.HasSuffix(filename, "_test.go")
+}
+
 type PackageAnalyzeResult struct {
 	AllTypeNames []*TypeName
 	AllFunctions []*Function
@@ -43,6 +85,10 @@ type PackageAnalyzeResult struct {
 	AllImports   []*Import
 	SourceFiles  []SourceFileInfo
 	Directory    string
+
+	// Examples for the whole package.
+	// Examples for type names and functions are attached to them.
+	Examples []*Example
 }
 
 func NewPackageAnalyzeResult() *PackageAnalyzeResult {
@@ -220,6 +266,8 @@ type TypeName struct {
 	Pkg     *Package // some duplicated with types.TypeName.Pkg(), except builtin types
 	AstDecl *ast.GenDecl
 	AstSpec *ast.TypeSpec
+
+	Examples []*Example
 }
 
 //func (tn *TypeName) IndexString() string {
@@ -255,6 +303,10 @@ func (tn *TypeName) Position() token.Position {
 	return tn.Pkg.PPkg.Fset.PositionFor(tn.AstSpec.Name.Pos(), false)
 }
 
+func (tn *TypeName) TestOnly() bool {
+	return IsTestFile(tn.Position().Filename)
+}
+
 func (tn *TypeName) Documentation() string {
 	//doc := tn.AstDecl.Doc.Text()
 	//if t := tn.AstSpec.Doc.Text(); t != "" {
@@ -509,6 +561,10 @@ func (c *Constant) Package() *Package {
 	return c.Pkg
 }
 
+func (c *Constant) TestOnly() bool {
+	return IsTestFile(c.Position().Filename)
+}
+
 func (c *Constant) Exported() bool {
 	if c.Pkg.Path() == "builtin" {
 		return !token.IsExported(c.Name())
@@ -585,6 +641,10 @@ func (v *Variable) Package() *Package {
 	return v.Pkg
 }
 
+func (v *Variable) TestOnly() bool {
+	return IsTestFile(v.Position().Filename)
+}
+
 func (v *Variable) Exported() bool {
 	if v.Pkg.Path() == "builtin" {
 		return !token.IsExported(v.Name())
@@ -638,6 +698,8 @@ type Function struct {
 	Type    *TypeInfo
 	Pkg     *Package // some duplicated with types.Func.Pkg(), except builtin functions
 	AstDecl *ast.FuncDecl
+
+	Examples []*Example
 }
 
 func (f *Function) Name() string {
@@ -661,6 +723,10 @@ func (f *Function) Position() token.Position {
 	return f.Pkg.PPkg.Fset.PositionFor(f.AstDecl.Name.Pos(), false)
 }
 
+func (f *Function) TestOnly() bool {
+	return f.Builtin == nil && IsTestFile(f.Position().Filename)
+}
+
 func (f *Function) Documentation() string {
 	// ToDo: html escape
 	return f.AstDecl.Doc.Text()
@@ -854,10 +920,16 @@ type Field struct {
 }
 
 func (fld *Field) Position() token.Position {
+	if fld.AstField == nil { // fields of instantiated types
+		return token.Position{}
+	}
 	return fld.Pkg.PPkg.Fset.PositionFor(fld.AstField.Pos(), false)
 }
 
 func (fld *Field) Documentation() string {
+	if fld.AstField == nil {
+		return ""
+	}
 	if doc := fld.AstField.Doc; doc != nil {
 		return doc.Text()
 	}
@@ -865,6 +937,9 @@ func (fld *Field) Documentation() string {
 }
 
 func (fld *Field) Comment() string {
+	if fld.AstField == nil {
+		return ""
+	}
 	if comment := fld.AstField.Comment; comment != nil {
 		return comment.Text()
 	}
@@ -883,15 +958,20 @@ type Method struct {
 	PointerRecv         bool // duplicated info, for faster access
 	ImplementsSomething bool // false if the method is unimportant for its reveiver to implement some interface type
 
+	// For the methods of instantiated types, Type is substituted with
+	// the type arguments, but the AST nodes are the generic ones.
+	Instantiated bool
+
 	index uint32 // 0 means this method doesn;t contribute to any type implementations for sure.
 }
 
 func (mthd *Method) Position() token.Position {
 	if mthd.AstFunc != nil { // method declaration
 		return mthd.Pkg.PPkg.Fset.PositionFor(mthd.AstFunc.Pos(), false)
-	} else { // if mthd.AstField != nil //initerface method specification
+	} else if mthd.AstField != nil { //initerface method specification
 		return mthd.Pkg.PPkg.Fset.PositionFor(mthd.AstField.Pos(), false)
 	}
+	return token.Position{} // methods of instantiated interfaces
 }
 
 func (mthd *Method) Documentation() string {
@@ -899,7 +979,7 @@ func (mthd *Method) Documentation() string {
 		if doc := mthd.AstFunc.Doc; doc != nil {
 			return doc.Text()
 		}
-	} else { // if mthd.AstField != nil //initerface method specification
+	} else if mthd.AstField != nil { //initerface method specification
 		if doc := mthd.AstField.Doc; doc != nil {
 			return doc.Text()
 		}
@@ -960,7 +1040,28 @@ func (s *Selector) Object() types.Object {
 				return s.Field.Pkg.PPkg.TypesInfo.ObjectOf(ident)
 			}
 		}
-		return nil // ToDo: handle the embedded field case
+		if s.Field.AstField == nil || len(s.Field.AstField.Names) > 0 {
+			return nil
+		}
+		// For an embedded field, the field object is defined by the type name identifier.
+		for expr := s.Field.AstField.Type; ; {
+			switch e := expr.(type) {
+			case *ast.StarExpr:
+				expr = e.X
+			case *ast.ParenExpr:
+				expr = e.X
+			case *ast.IndexExpr: // instantiated types
+				expr = e.X
+			case *ast.IndexListExpr: // instantiated types
+				expr = e.X
+			case *ast.SelectorExpr:
+				expr = e.Sel
+			case *ast.Ident:
+				return s.Field.Pkg.PPkg.TypesInfo.ObjectOf(e)
+			default:
+				return nil
+			}
+		}
 	}
 
 	// Non-interface method
@@ -1066,3 +1167,24 @@ func PrintSelectors(title string, selectors []*Selector) {
 		log.Println("  ", sel)
 	}
 }
+
+// Example represents an example function declared in a _test.go file.
+// The _test.go files are not type checked, so only AST info is available.
+type Example struct {
+	*doc.Example
+
+	Pkg  *Package
+	Fset *token.FileSet // the file set the example code belongs to
+
+	// The name of the example, excluding the suffix.
+	// For example, "T_M" for ExampleT_M_suffix.
+	Exemplified string
+}
+
+// Suffix returns the example suffix (without the leading '_'), or "".
+func (e *Example) Suffix() string {
+	if len(e.Name) > len(e.Exemplified) {
+		return e.Name[len(e.Exemplified)+1:]
+	}
+	return ""
+}
@@ -0,0 +1,278 @@
+package code
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// A Platform is a GOOS/GOARCH composition.
+type Platform struct {
+	GOOS   string
+	GOARCH string
+}
+
+func (p Platform) String() string {
+	return p.GOOS + "/" + p.GOARCH
+}
+
+// ParsePlatforms parses a comma separated platform list,
+// such as "linux/amd64,windows/amd64".
+func ParsePlatforms(list string) ([]Platform, error) {
+	var platforms []Platform
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		i := strings.IndexByte(s, '/')
+		if i <= 0 || i == len(s)-1 {
+			return nil, fmt.Errorf("invalid platform: %s (should be GOOS/GOARCH)", s)
+		}
+		p := Platform{GOOS: s[:i], GOARCH: s[i+1:]}
+		for _, q := range platforms {
+			if q == p {
+				return nil, fmt.Errorf("duplicated platform: %s", s)
+			}
+		}
+		platforms = append(platforms, p)
+	}
+	// A bit set is used to record the platforms of a declaration.
+	if len(platforms) > 64 {
+		return nil, fmt.Errorf("too many platforms (%d)", len(platforms))
+	}
+	return platforms, nil
+}
+
+func platformEnv(p Platform) []string {
+	return append(os.Environ(), "GOOS="+p.GOOS, "GOARCH="+p.GOARCH)
+}
+
+// Declarations on different platforms are merged by their identities,
+// such as "type T", "func F", "var V", "const C" and "file a_linux.go".
+// Methods are not merged now.
+func declarationIdentity(kind, name string) string {
+	return kind + " " + name
+}
+
+// A PlatformDeclaration is a package-level declaration
+// which doesn't exist on the primary platform.
+type PlatformDeclaration struct {
+	Kind      string // "type", "func", "var", "const" or "file"
+	Name      string
+	Platforms []Platform
+
+	// Such declarations are not type-checked, so only their
+	// docs and source code are recorded. Both are blank for files.
+	Doc    string
+	Source string // without function bodies
+}
+
+// The docs and source code of the declarations not on the primary platform.
+type platformDeclarationText struct {
+	doc    string
+	source string
+}
+
+// collectPlatformDeclarations parses the packages on the non-primary platforms
+// (only syntax is needed), then records the platforms of each package-level
+// declaration and source file. It is called at the end of ParsePackages.
+func (d *CodeAnalyzer) collectPlatformDeclarations(options ParseOptions, args []string) {
+	for _, pkg := range d.packageList {
+		pkg.platformDecls = make(map[string]uint64, 64)
+		collectDeclarationIdentities(pkg.PPkg, func(id string, _ *ast.CommentGroup, _ ast.Node) {
+			pkg.platformDecls[id] |= 1
+		})
+	}
+
+	for i, p := range options.Platforms[1:] {
+		var bit uint64 = 1 << uint(i+1)
+		var config = &packages.Config{
+			Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports |
+				packages.NeedDeps | packages.NeedSyntax,
+			Env: platformEnv(p),
+		}
+		ppkgs, err := packages.Load(config, args...)
+		if err != nil {
+			log.Printf("packages.Load (parse packages for %s): %s", p, err)
+			continue
+		}
+		// Packages failing to load on a platform are viewed as absent on it.
+		packages.Visit(ppkgs, nil, func(ppkg *packages.Package) {
+			pkg := d.packageTable[ppkg.PkgPath]
+			if pkg == nil {
+				return // only used on this platform
+			}
+			collectDeclarationIdentities(ppkg, func(id string, doc *ast.CommentGroup, decl ast.Node) {
+				if pkg.platformDecls[id] == 0 && decl != nil {
+					if pkg.platformDeclTexts == nil {
+						pkg.platformDeclTexts = make(map[string]platformDeclarationText)
+					}
+					var buf bytes.Buffer
+					if err := format.Node(&buf, ppkg.Fset, decl); err != nil {
+						log.Printf("print %s of %s for %s: %s", id, ppkg.PkgPath, p, err)
+					}
+					pkg.platformDeclTexts[id] = platformDeclarationText{doc: doc.Text(), source: buf.String()}
+				}
+				pkg.platformDecls[id] |= bit
+			})
+		})
+	}
+}
+
+// Test files are ignored, for they are not loaded on the non-primary platforms.
+// Besides the identity, onIdentity is also passed the doc comment of the
+// declaration and a node to print the declaration (nil for files).
+func collectDeclarationIdentities(ppkg *packages.Package, onIdentity func(id string, doc *ast.CommentGroup, decl ast.Node)) {
+	for _, filename := range ppkg.GoFiles {
+		if !IsTestFile(filename) {
+			onIdentity(declarationIdentity("file", filepath.Base(filename)), nil, nil)
+		}
+	}
+
+	for _, file := range ppkg.Syntax {
+		if IsTestFile(ppkg.Fset.File(file.Pos()).Name()) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.Name != "init" {
+					onIdentity(declarationIdentity("func", decl.Name.Name), decl.Doc,
+						&ast.FuncDecl{Name: decl.Name, Type: decl.Type})
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					// Same as go/doc, the doc of a single-spec
+					// declaration is viewed as the one of the spec.
+					var doc *ast.CommentGroup
+					if len(decl.Specs) == 1 {
+						doc = decl.Doc
+					}
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if spec.Doc != nil {
+							doc = spec.Doc
+						}
+						copied := *spec
+						copied.Doc, copied.Comment = nil, nil
+						onIdentity(declarationIdentity("type", spec.Name.Name), doc,
+							&ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&copied}})
+					case *ast.ValueSpec:
+						kind := "var"
+						if decl.Tok == token.CONST {
+							kind = "const"
+						}
+						if spec.Doc != nil {
+							doc = spec.Doc
+						}
+						copied := *spec
+						copied.Doc, copied.Comment = nil, nil
+						for _, name := range spec.Names {
+							if name.Name != "_" {
+								onIdentity(declarationIdentity(kind, name.Name), doc,
+									&ast.GenDecl{Tok: decl.Tok, Specs: []ast.Spec{&copied}})
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// Platforms returns the platforms whose docs are merged.
+// The first one is the primary one, on which the packages are analyzed.
+// Nil is returned if no platforms are specified (the current one is used).
+func (d *CodeAnalyzer) Platforms() []Platform {
+	return d.platforms
+}
+
+// Nil is returned if the bits contain all the platforms.
+func (d *CodeAnalyzer) platformsOfBits(bits uint64) []Platform {
+	if bits == 1<<uint(len(d.platforms))-1 {
+		return nil
+	}
+	var platforms []Platform
+	for i, p := range d.platforms {
+		if bits&(1<<uint(i)) != 0 {
+			platforms = append(platforms, p)
+		}
+	}
+	return platforms
+}
+
+// PlatformsOfResource returns the platforms on which res exists.
+// Nil is returned if it exists on all the platforms.
+func (d *CodeAnalyzer) PlatformsOfResource(res Resource) []Platform {
+	var kind string
+	switch res := res.(type) {
+	default:
+		return nil
+	case *TypeName:
+		kind = "type"
+	case *Function:
+		if res.IsMethod() {
+			return nil
+		}
+		kind = "func"
+	case *Variable:
+		kind = "var"
+	case *Constant:
+		kind = "const"
+	}
+	return d.platformsOf(res.Package(), declarationIdentity(kind, res.Name()))
+}
+
+// PlatformsOfSourceFile returns the platforms on which the file
+// of pkg is used. Nil is returned if it is used on all the platforms.
+func (d *CodeAnalyzer) PlatformsOfSourceFile(pkg *Package, filename string) []Platform {
+	return d.platformsOf(pkg, declarationIdentity("file", filepath.Base(filename)))
+}
+
+func (d *CodeAnalyzer) platformsOf(pkg *Package, identity string) []Platform {
+	if pkg == nil || pkg.platformDecls == nil {
+		return nil
+	}
+	bits, ok := pkg.platformDecls[identity]
+	if !ok {
+		return nil
+	}
+	return d.platformsOfBits(bits)
+}
+
+// DeclarationsOnOtherPlatforms returns the declarations and source files
+// of pkg which don't exist on the primary platform, sorted by kinds and names.
+func (d *CodeAnalyzer) DeclarationsOnOtherPlatforms(pkg *Package) []PlatformDeclaration {
+	var decls []PlatformDeclaration
+	for id, bits := range pkg.platformDecls {
+		if bits&1 != 0 {
+			continue
+		}
+		i := strings.IndexByte(id, ' ')
+		text := pkg.platformDeclTexts[id]
+		decls = append(decls, PlatformDeclaration{
+			Kind:      id[:i],
+			Name:      id[i+1:],
+			Platforms: d.platformsOfBits(bits),
+			Doc:       text.doc,
+			Source:    text.source,
+		})
+	}
+	sort.Slice(decls, func(i, j int) bool {
+		if decls[i].Kind != decls[j].Kind {
+			return decls[i].Kind < decls[j].Kind
+		}
+		return decls[i].Name < decls[j].Name
+	})
+	return decls
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
+	"go/types"
 	"io/ioutil"
 	"log"
 	"path/filepath"
@@ -248,14 +249,78 @@ func (d *CodeAnalyzer) CollectObjectReferences() {
 }
 
 func (d *CodeAnalyzer) CollectIdentiferFromFile(pkg *Package, fileInfo *SourceFileInfo) {
+	info := pkg.PPkg.TypesInfo
+
+	// Implicit refs are registered when the nodes using them are visited,
+	// so that the refs of each object are still sorted by positions.
+	// * the elements of unkeyed struct literals use the corresponding fields.
+	// * promoted selectors use the embedded fields in their paths.
+	var unkeyedElements map[ast.Expr]*types.Var
+	var promotedSelectors map[*ast.Ident][]*types.Var
+
 	ast.Inspect(fileInfo.AstFile, func(n ast.Node) bool {
+		if e, ok := n.(ast.Expr); ok && unkeyedElements != nil {
+			if field := unkeyedElements[e]; field != nil {
+				d.regImplicitObjectReference(field, fileInfo, e)
+			}
+		}
+
 		switch n := n.(type) {
 		case *ast.Ident:
-			obj := pkg.PPkg.TypesInfo.ObjectOf(n)
+			obj := info.ObjectOf(n)
 			if obj != nil {
 				d.regObjectReference(obj, fileInfo, n)
 			}
-			// ToDo: also collect some implicit refs.
+			for _, field := range promotedSelectors[n] {
+				d.regObjectReference(field, fileInfo, n)
+			}
+		case *ast.SelectorExpr:
+			sel, ok := info.Selections[n]
+			if !ok || len(sel.Index()) < 2 {
+				break
+			}
+			t := sel.Recv()
+			for _, i := range sel.Index()[:len(sel.Index())-1] {
+				if ptr, ok := t.Underlying().(*types.Pointer); ok {
+					t = ptr.Elem()
+				}
+				st, ok := t.Underlying().(*types.Struct)
+				if !ok {
+					break
+				}
+				field := st.Field(i).Origin()
+				if promotedSelectors == nil {
+					promotedSelectors = make(map[*ast.Ident][]*types.Var)
+				}
+				promotedSelectors[n.Sel] = append(promotedSelectors[n.Sel], field)
+				t = field.Type()
+			}
+		case *ast.CompositeLit:
+			if len(n.Elts) == 0 {
+				break
+			}
+			if _, ok := n.Elts[0].(*ast.KeyValueExpr); ok {
+				break
+			}
+			t := info.TypeOf(n)
+			if t == nil {
+				break
+			}
+			if ptr, ok := t.Underlying().(*types.Pointer); ok { // elided &T{...}
+				t = ptr.Elem()
+			}
+			st, ok := t.Underlying().(*types.Struct)
+			if !ok {
+				break
+			}
+			if unkeyedElements == nil {
+				unkeyedElements = make(map[ast.Expr]*types.Var)
+			}
+			for i, elt := range n.Elts {
+				if i < st.NumFields() {
+					unkeyedElements[elt] = st.Field(i).Origin()
+				}
+			}
 		}
 		return true
 	})
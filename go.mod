@@ -1,9 +1,11 @@
 module go101.org/golds
 
-go 1.13
+go 1.26.0
 
 require (
-	golang.org/dl v0.0.0-20201217181409-aeefed14b4e2 // indirect
-	golang.org/x/text v0.3.3
-	golang.org/x/tools v0.0.0-20200804011535-6c149bb5ef0d
+	golang.org/x/mod v0.41.0
+	golang.org/x/text v0.42.0
+	golang.org/x/tools v0.50.0
 )
+
+require golang.org/x/sync v0.23.0 // indirect
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"go101.org/golds/code"
 	"go101.org/golds/internal/server"
 	"go101.org/golds/internal/util"
 )
@@ -81,7 +82,7 @@ func Run() {
 	silentMode := *silentFlag || *sFlag
 
 	// files serving mode
-	if flag.NArg() == 0 {
+	if flag.NArg() == 0 && !*diffFlag {
 		log.SetFlags(0)
 
 		if *dirFlag == "" {
@@ -144,6 +145,27 @@ func Run() {
 		*plainsrc = true
 	}
 
+	platforms, err := code.ParsePlatforms(*platformsFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var importRules []code.ImportRule
+	if *importRulesFlag != "" {
+		f, err := os.Open(*importRulesFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		importRules, err = code.ParseImportRules(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("parse %s error: %s", *importRulesFlag, err)
+		}
+		if importRules == nil {
+			importRules = []code.ImportRule{} // still show the (empty) violation list
+		}
+	}
+
 	options := server.PageOutputOptions{
 		GoldsVersion:          Version,
 		PreferredLang:         *langFlag,
@@ -152,6 +174,24 @@ func Run() {
 		//EmphasizeWDPkgs:       emphasizeWDPkgs,
 		WdPkgsListingManner: wdPkgsListingManner,
 		FooterShowingManner: footerShowingManner,
+		IncludeTests:        *testsFlag,
+		WatchSourceChanges:  *watchFlag,
+		CachePagesOnDisk:    *cacheFlag,
+		Theme:               *themeFlag,
+		ThemeFile:           *themeFileFlag,
+		Platforms:           platforms,
+		ImportRules:         importRules,
+	}
+
+	// API diff mode
+	if *diffFlag {
+		if flag.NArg() < 2 {
+			log.Println("Two snapshots (directories or git revisions) are needed to compare APIs")
+			printUsage(os.Stdout)
+			os.Exit(1)
+		}
+		server.DiffAPI(options, flag.Arg(0), flag.Arg(1), flag.Args()[2:], os.Stdout, *targetFlag == "json", printUsage)
+		return
 	}
 
 	// static docs generating mode
@@ -163,12 +203,20 @@ func Run() {
 			//printUsage(os.Stdout)
 		case "testdata":
 			server.GenTestData(flag.Args(), outputDir, silentMode, printUsage)
+		case "unused":
+			server.GenUnusedReport(options, flag.Args(), os.Stdout, printUsage)
 		case "docs":
-			viewDocsCommand := func(docsDir string) string {
-				return os.Args[0] + " -dir=" + docsDir
+			switch target := *targetFlag; target {
+			default:
+				log.Println("Unknown gen target:", target)
+			case "html":
+				viewDocsCommand := func(docsDir string) string {
+					return os.Args[0] + " -dir=" + docsDir
+				}
+				server.GenDocs(options, flag.Args(), outputDir, silentMode, printUsage, *moregcFlag, viewDocsCommand)
+			case "json":
+				server.GenJSON(options, flag.Args(), outputDir, silentMode, printUsage, *moregcFlag)
 			}
-			// ToDo: also support json format output
-			server.GenDocs(options, flag.Args(), outputDir, silentMode, printUsage, *moregcFlag, viewDocsCommand)
 		}
 
 		return
@@ -195,8 +243,14 @@ var helpFlag = flag.Bool("help", false, "show help")
 //var updateFlag = flag.Bool("update", false, "update self")
 var versionFlag = flag.Bool("version", false, "show version info")
 var genFlag = flag.Bool("gen", false, "HTML generation mode")
-var genIntentFlag = flag.String("gen-intent", "docs", "docs | testdata")
+var genIntentFlag = flag.String("gen-intent", "docs", "docs | unused | testdata")
+var targetFlag = flag.String("target", "html", "html | json")
+var diffFlag = flag.Bool("diff", false, "compare the exported APIs of two snapshots")
 var langFlag = flag.String("lang", "", "docs generation language tag")
+var themeFlag = flag.String("theme", "", "page theme: light | dark")
+var themeFileFlag = flag.String("theme-file", "", "a CSS file used as a custom theme")
+var platformsFlag = flag.String("platforms", "", "GOOS/GOARCH list, such as linux/amd64,windows/amd64")
+var importRulesFlag = flag.String("import-rules", "", "a file of allowed/forbidden import rules")
 var dirFlag = flag.String("dir", "", "directory for file serving or HTML generation")
 var portFlag = flag.String("port", "", "preferred server port [1024, 65536]. Default: 56789 or 9999")
 var sFlag = flag.Bool("s", false, "not open a browser automatically")
@@ -206,6 +260,9 @@ var footerFlag = flag.String("footer", "verbose", "verbose | simple | none")
 var nouses = flag.Bool("nouses", false, "disable the identifier uses feature")
 var plainsrc = flag.Bool("plainsrc", false, "disable the source navigation feature")
 var compact = flag.Bool("compact", false, "sacrifice some disk-consuming features in generation")
+var testsFlag = flag.Bool("tests", false, "also analyze test packages and test files")
+var watchFlag = flag.Bool("watch", false, "re-analyze packages when source files change")
+var cacheFlag = flag.Bool("cache", false, "cache a snapshot of the docs on disk")
 
 // depreciated by "-wdpkgs-listing=promoted" since v0.1.8
 var emphasizeWdPackagesFlag = flag.Bool("emphasize-wdpkgs", false, "promote working directory packages")
@@ -259,6 +316,58 @@ Options:
 		serving diretory. Current directory
 		will be used if no arguments specified.
 		"memory" means not to save (for testing).
+	-target=html|json
+		Specify the format of the generated docs
+		(default is html). The json format dumps
+		the analysis results (packages, types,
+		selectors, values, implementations and
+		references) as versioned JSON files.
+		For docs generation mode only.
+	-gen-intent=docs|unused
+		Specify what to generate (default is docs).
+		"unused" means to print the exported
+		package-level identifiers (in the packages
+		under the current directory) which are not
+		used by other packages, instead of docs.
+		For docs generation mode only.
+	-diff <OldSnapshot> <NewSnapshot>
+		API diff mode. Compare the exported APIs
+		of the packages under the current
+		directory in two snapshots, each of which
+		is a directory or a git revision. Added,
+		removed and changed declarations, method
+		sets and implementation relations are
+		listed (as JSON if -target=json is set).
+	-theme=light|dark
+		Specify the page theme (default is light).
+		In docs serving mode, the theme may be also
+		switched in the page headers.
+	-theme-file=<CSSFile>
+		Use the CSS file as a custom theme. The
+		theme name is the file name without the
+		extension. It is the default theme if the
+		-theme option is not specified. Warnings
+		are shown if the CSS file doesn't style
+		some classes which pages rely on.
+	-platforms=<GOOS/GOARCH,...>
+		Merge docs for several platforms, such as
+		-platforms=linux/amd64,windows/amd64.
+		Code is analyzed on the first platform.
+		Declarations and files which don't exist
+		on all the platforms are annotated with
+		the platforms where they exist.
+	-import-rules=<RulesFile>
+		Check package imports against the rules
+		in the file. Each rule is a line like
+		"forbid x.y/internal/store/... x.y/http"
+		or "allow <Importer> <Imported>". Like the
+		go command, "..." in patterns matches any
+		string. "std" matches standard packages.
+		For each import, the last matching rule
+		wins. Violations are shown in the overview
+		and package details pages. In docs
+		generation mode, Golds exits with a
+		non-zero code if there are violations.
 	-nouses
 		Disable the identifier uses feature.
 		For HTML docs generation mode only.
@@ -269,6 +378,31 @@ Options:
 		This is a shortcut of the combination
 		of several other options, including
 		-nouses and -plainsrc now.
+	-tests
+		Also analyze the _test.go files and the
+		external test packages. The declarations
+		in test files are test-only declarations.
+		They are hidden in package details pages
+		when only exported resources are listed.
+	-watch
+		Watch the source files of the packages
+		in the current directory and re-analyze
+		the packages in background when the
+		files change. The old docs are served
+		before the re-analysis finishes.
+		For docs serving mode only.
+	-cache
+		Cache a snapshot of the docs in the user
+		cache directory. After the analysis
+		finishes, all the pages are rendered in
+		background and cached. When the analyzed
+		code is unchanged, the cached pages are
+		served immediately in later runs, before
+		the analysis finishes. Each cache file is
+		at most 256MiB. The cache files of the
+		least recently used projects are removed
+		when there are more than 8 ones.
+		For docs serving mode only.
 	-emphasize-wdpkgs (depreciated)
 		List the packages under the current
 		directory before other pacakges.
@@ -306,6 +440,18 @@ Examples:
 		specified by the -dir flag for the
 		packages under the current directory
 		and their dependency packages.
+	%[1]v -gen -target=json -dir=./generated ./...
+		Dump the analysis results of the packages
+		under the current directory and their
+		dependency packages as JSON files.
+	%[1]v -diff v1.2.0 HEAD ./...
+		List the API changes of the packages
+		under the current directory between
+		the v1.2.0 tag and the HEAD revision.
+	%[1]v -gen -gen-intent=unused ./...
+		List the exported identifiers of the
+		packages under the current directory
+		which are not used by other packages.
 	%[1]v -dir=. -s
 		Serve the files in working directory
 		without opening a browser window.
@@ -1,12 +1,21 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"go101.org/golds/code"
+	theme "go101.org/golds/internal/server/themes"
 	"go101.org/golds/internal/util"
 )
 
@@ -58,6 +67,99 @@ func TestRelativePath(t *testing.T) {
 	}
 }
 
+func TestInWorkingDirectory(t *testing.T) {
+	var testCases = []struct {
+		wd, dir string
+		in      bool
+	}{
+		{"/a/foo", "/a/foo", true},
+		{"/a/foo", "/a/foo/bar", true},
+		{"/a/foo", "/a/foobar", false},
+		{"/a/foo", "/a", false},
+		{"/a/foo", "", false},
+		{"/", "/a", true},
+	}
+	for _, tc := range testCases {
+		ds := &docServer{workingDirectory: filepath.FromSlash(tc.wd)}
+		if in := ds.inWorkingDirectory(filepath.FromSlash(tc.dir)); in != tc.in {
+			t.Errorf("inWorkingDirectory(%s, %s): %v vs. %v", tc.wd, tc.dir, in, tc.in)
+		}
+	}
+}
+
+func TestPageCacheFile(t *testing.T) {
+	cache := &diskPageCache{
+		filename:    filepath.Join(t.TempDir(), "a.pages"),
+		fingerprint: "abc",
+	}
+	if err := cache.reset(); err != nil {
+		t.Fatal(err)
+	}
+	cache.recording = true
+	cache.add("light English /pkg:a", diskCachedPage{"text/html", []byte("a")})
+	cache.add("light English /pkg:a", diskCachedPage{"text/html", []byte("b")}) // ignored
+	cache.add("light English /pkg:b", diskCachedPage{"text/html", nil})
+	cache.finishSnapshot()
+	cache.close()
+
+	pages, snapshotted, err := readPageCacheFile(cache.filename, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snapshotted || len(pages) != 2 || string(pages["light English /pkg:a"].content) != "a" {
+		t.Errorf("read page cache file: snapshotted=%v, pages=%v", snapshotted, pages)
+	}
+	if pages, _, _ := readPageCacheFile(cache.filename, "def"); pages != nil {
+		t.Errorf("outdated page cache file should not be used")
+	}
+
+	for path, cacheable := range map[string]bool{"/": false, "/update": false, "/pkg:a": true, "/src:a/a.go": true} {
+		if isSnapshotLink(path) != cacheable {
+			t.Errorf("%s: cacheable should be %v", path, cacheable)
+		}
+	}
+	if isSnapshotLink("/pkg:a?theme=dark") {
+		t.Errorf("links changing settings should not be followed")
+	}
+}
+
+func TestRemoveLeastRecentlyUsedPageCacheFiles(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, "current.pages")
+	if err := ioutil.WriteFile(current, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < maxPageCacheFiles+2; i++ {
+		filename := filepath.Join(dir, fmt.Sprintf("%d.pages", i))
+		if err := ioutil.WriteFile(filename, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		mtime := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(filename, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removeLeastRecentlyUsedPageCacheFiles(dir, current)
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != maxPageCacheFiles {
+		t.Fatalf("%d cache files are kept, want %d", len(infos), maxPageCacheFiles)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("%d.pages", i))); !os.IsNotExist(err) {
+			t.Errorf("the least recently used cache file %d.pages should be removed", i)
+		}
+	}
+	if _, err := os.Stat(current); err != nil {
+		t.Errorf("the current cache file should be kept: %s", err)
+	}
+}
+
 func TestBuildPageHref(t *testing.T) {
 	type testCase struct {
 		from, to pagePathInfo
@@ -231,8 +333,200 @@ func TestDocsForStandardPackages(t *testing.T) {
 	}
 }
 
+func TestSearchIndex(t *testing.T) {
+	entry := func(matchName, qualified, doc string) *searchEntry {
+		return &searchEntry{matchName: matchName, qualified: qualified, lowerDoc: doc}
+	}
+	index := &searchIndex{entries: []*searchEntry{
+		entry("readall", "io.readall", "readall reads from r until an error or eof."),
+		entry("reader", "io.reader", "reader is the interface that wraps the basic read method."),
+		entry("read", "io.reader.read", ""),
+		entry("read", "bytes.reader.read", "read implements the io.reader interface."),
+		entry("io", "io", "package io provides basic interfaces to i/o primitives."),
+	}}
+	type testCase struct {
+		query    string
+		expected []string
+	}
+	var testCases = []testCase{
+		{"", nil},
+		{"nothing", nil},
+		{"Read", []string{"io.reader.read", "bytes.reader.read", "io.reader", "io.readall"}},
+		{"io.reader", []string{"io.reader", "io.reader.read", "bytes.reader.read"}},
+		{"read eof", []string{"io.readall"}},
+		{"primitives", []string{"io"}},
+	}
+	for _, tc := range testCases {
+		var results []string
+		for _, e := range index.search(tc.query) {
+			results = append(results, e.qualified)
+		}
+		if strings.Join(results, " ") != strings.Join(tc.expected, " ") {
+			t.Errorf("search %q: got %v, expected %v", tc.query, results, tc.expected)
+		}
+	}
+}
+
+func TestBuiltinThemesStyleRequiredSelectors(t *testing.T) {
+	for _, th := range []Theme{&theme.Light{}, &theme.Dark{}} {
+		if missings := theme.MissingSelectors(th.CSS()); len(missings) > 0 {
+			t.Errorf("theme %s doesn't style: %v", th.Name(), missings)
+		}
+	}
+	if missings := theme.MissingSelectors(".fold-items {}"); len(missings) != len(theme.RequiredSelectors)-1 {
+		t.Errorf("missing selectors of a partial theme: %v", missings)
+	}
+}
+
+func TestTypeHierarchy(t *testing.T) {
+	const pkgPath = "go101.org/golds/internal/testing/html-checking/hierarchy"
+	ds := &docServer{
+		phase:    Phase_Unprepared,
+		analyzer: &code.CodeAnalyzer{},
+	}
+	ds.initSettings("en-US")
+	ds.analyze([]string{pkgPath}, PageOutputOptions{}, nil)
+
+	type listedType struct {
+		name      string
+		isPointer bool
+	}
+	groupsOf := func(result *TypeHierarchyResult) map[string][]listedType {
+		groups := make(map[string][]listedType)
+		for _, g := range result.Implementations {
+			for _, t := range g.Types {
+				groups[g.Package.Path()] = append(groups[g.Package.Path()], listedType{t.Name(), t.IsPointer})
+			}
+		}
+		return groups
+	}
+
+	result, err := ds.buildTypeHierarchyData(pkgPath, "T")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Embeddings) != 1 || result.Embeddings[0].Field.Name != "Inner" ||
+		len(result.Embeddings[0].Children) != 1 || result.Embeddings[0].Children[0].Field.Name != "closer" {
+		t.Errorf("the embedding tree of T should be Inner > closer")
+	}
+	groups := groupsOf(result)
+	if expected := []listedType{{"Closer", false}, {"ReadCloser", true}, {"Reader", true}}; !reflect.DeepEqual(groups["io"], expected) {
+		t.Errorf("T implements %v in io, expected %v", groups["io"], expected)
+	}
+	if expected := []listedType{{"ReadCloser", true}}; !reflect.DeepEqual(groups[pkgPath], expected) {
+		t.Errorf("T implements %v in its package, expected %v", groups[pkgPath], expected)
+	}
+
+	result, err = ds.buildTypeHierarchyData(pkgPath, "ReadCloser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Embeddings) != 2 || result.Embeddings[0].Field.Name != "Reader" || result.Embeddings[1].Field.Name != "Closer" {
+		t.Errorf("ReadCloser should embed io.Reader and io.Closer")
+	}
+	if expected := []listedType{{"T", true}}; !reflect.DeepEqual(groupsOf(result)[pkgPath], expected) {
+		t.Errorf("ReadCloser is implemented by %v in its package, expected %v", groupsOf(result)[pkgPath], expected)
+	}
+
+	if page := renderTestPage(ds, "/hie:"+pkgPath+".T"); !strings.Contains(page, "*T : io.") || strings.Contains(page, "*io.") {
+		t.Errorf("the implemented interfaces should be listed as *T : io.Reader")
+	}
+}
+
+func TestDeprecatedDeclarations(t *testing.T) {
+	ds := &docServer{
+		phase:    Phase_Unprepared,
+		analyzer: &code.CodeAnalyzer{},
+	}
+	ds.initSettings("en-US")
+	ds.analyze([]string{"io/ioutil"}, PageOutputOptions{}, nil)
+
+	page := renderTestPage(ds, "/pkg:io/ioutil")
+	if !strings.Contains(page, `<span class="deprecated">`) {
+		t.Errorf("deprecated declarations in io/ioutil are not struck through")
+	}
+	if !strings.Contains(page, `<input type='checkbox' class="fold" id="ReadAll-fold-docs">`) {
+		t.Errorf("the docs of the deprecated ioutil.ReadAll are not collapsed")
+	}
+}
+
+// renderTestPage returns the content of the page at path.
+// Server tests run in docs generation mode.
+func renderTestPage(ds *docServer, path string) string {
+	w := &docGenResponseWriter{}
+	w.reset()
+	ds.ServeHTTP(w, &http.Request{Method: http.MethodGet, URL: &url.URL{Path: path}})
+	defer contentPool.collect(w.content)
+	return string(bytes.Join(w.content, nil))
+}
+
+func TestGenerateJSON(t *testing.T) {
+	const pkgPath = "go101.org/golds/internal/testing/html-checking/hierarchy"
+	dir := t.TempDir()
+	GenJSON(PageOutputOptions{GoldsVersion: "v0.0.0", PreferredLang: "en-US"}, []string{pkgPath}, dir, true, nil, false)
+
+	matches, err := filepath.Glob(filepath.Join(dir, "generated-json-*", "index.json"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("index.json is not generated: %v", err)
+	}
+	readJson := func(filename string, v interface{}) {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatalf("decode %s: %s", filename, err)
+		}
+	}
+
+	var index JsonData_Index
+	readJson(matches[0], &index)
+	if index.FormatVersion != JsonFormatVersion || index.GoldsVersion != "v0.0.0" {
+		t.Errorf("index versions: %d %s", index.FormatVersion, index.GoldsVersion)
+	}
+	var pkgFile string
+	for _, entry := range index.Packages {
+		if entry.ImportPath == pkgPath {
+			pkgFile = entry.File
+		}
+	}
+	if pkgFile == "" {
+		t.Fatalf("package %s is not listed in index.json", pkgPath)
+	}
+
+	var pkg JsonData_Package
+	readJson(filepath.Join(filepath.Dir(matches[0]), filepath.FromSlash(pkgFile)), &pkg)
+	if pkg.FormatVersion != JsonFormatVersion || pkg.ImportPath != pkgPath || pkg.Name != "hierarchy" {
+		t.Errorf("package header: %d %s %s", pkg.FormatVersion, pkg.ImportPath, pkg.Name)
+	}
+	var names []string
+	var typeT *JsonData_Type
+	for i, tt := range pkg.Types {
+		names = append(names, tt.Name)
+		if tt.Name == "T" {
+			typeT = &pkg.Types[i]
+		}
+	}
+	if expected := []string{"Inner", "ReadCloser", "T"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("type names: %v, expected %v", names, expected)
+	}
+	if !reflect.DeepEqual(typeT.Implements, []JsonData_TypeRef{
+		{pkgPath, "ReadCloser", true},
+		{"io", "Closer", false},
+		{"io", "ReadCloser", true},
+		{"io", "Reader", true},
+	}) {
+		t.Errorf("T implements %v", typeT.Implements)
+	}
+	// T is referenced in its declaration, a method receiver and "var _ ReadCloser = (*T)(nil)".
+	if refs := typeT.References; len(refs) != 3 || refs[2] != (JsonData_Position{pkgPath + "/hierarchy.go", 24, 22}) {
+		t.Errorf("references of T: %v", refs)
+	}
+}
+
 func TestGenerateDocsOfStandardPackages(t *testing.T) {
 	opts := PageOutputOptions{GoldsVersion: "v0.0.0", PreferredLang: "en-US"}
 	GenDocs(opts, []string{"std"}, "", true, nil, false, nil)
 	GenTestData([]string{"std"}, "", true, nil)
+	GenJSON(opts, []string{"std"}, "", true, nil, false)
 }
@@ -144,6 +144,7 @@ func (ds *docServer) loadAPI(w http.ResponseWriter, r *http.Request) {
 
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	if fromIndex > len(ds.analyzingLogs) {
 		fromIndex = len(ds.analyzingLogs)
@@ -197,6 +198,8 @@ func (ds *docServer) onAnalyzingSubTaskDone(task int, d time.Duration, args ...i
 			msg = ds.currentTranslation.Text_Analyzing_CollectObjectReferences(d)
 		case code.SubTask_CacheSourceFiles:
 			msg = ds.currentTranslation.Text_Analyzing_CacheSourceFiles(d)
+		case code.SubTask_CollectExamples:
+			msg = ds.currentTranslation.Text_Analyzing_CollectExamples(d)
 		}
 		return msg
 	}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"encoding/json"
+	"go/doc"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+const maxNumSearchResults = 100
+
+// The kinds of search entries.
+const (
+	SearchKind_Package  = "package"
+	SearchKind_Type     = "type"
+	SearchKind_Constant = "const"
+	SearchKind_Variable = "var"
+	SearchKind_Function = "func"
+	SearchKind_Method   = "method"
+	SearchKind_Field    = "field"
+)
+
+type searchEntry struct {
+	kind string
+	pkg  *code.Package
+	name string // "T.M" for methods and fields. Blank for packages.
+	doc  string
+
+	// The anchor in the package details page. Blank for packages.
+	anchor string
+
+	// The ones used in matching. They are all in lower case.
+	matchName string // the last part of the name, or the package name
+	qualified string // package path + "." + name
+	lowerDoc  string
+}
+
+func (e *searchEntry) Synopsis() string {
+	return doc.Synopsis(e.doc)
+}
+
+type searchIndex struct {
+	entries []*searchEntry
+}
+
+// Only exported resources are indexed. For non-test packages,
+// test-only declarations are not indexed either.
+func buildSearchIndex(analyzer *code.CodeAnalyzer) *searchIndex {
+	index := &searchIndex{entries: make([]*searchEntry, 0, 1024*64)}
+	add := func(kind string, pkg *code.Package, name, anchor, doc string) {
+		e := &searchEntry{
+			kind:     kind,
+			pkg:      pkg,
+			name:     name,
+			doc:      doc,
+			anchor:   anchor,
+			lowerDoc: strings.ToLower(doc),
+		}
+		if name == "" {
+			e.matchName = strings.ToLower(pkg.PPkg.Name)
+			e.qualified = strings.ToLower(pkg.Path())
+		} else {
+			e.matchName = strings.ToLower(name[strings.LastIndexByte(name, '.')+1:])
+			e.qualified = strings.ToLower(pkg.Path() + "." + name)
+		}
+		index.entries = append(index.entries, e)
+	}
+
+	for i, n := 0, analyzer.NumPackages(); i < n; i++ {
+		pkg := analyzer.PackageAt(i)
+		if pkg.PackageAnalyzeResult == nil {
+			continue
+		}
+
+		var pkgDoc string
+		for _, f := range pkg.PPkg.Syntax {
+			if f.Doc != nil {
+				pkgDoc = f.Doc.Text()
+				break
+			}
+		}
+		add(SearchKind_Package, pkg, "", "", pkgDoc)
+
+		hideTestOnlys := !pkg.IsExternalTest()
+
+		for _, tn := range pkg.AllTypeNames {
+			if !tn.Exported() || hideTestOnlys && tn.TestOnly() {
+				continue
+			}
+			anchor := "name-" + tn.Name()
+			add(SearchKind_Type, pkg, tn.Name(), anchor, tn.Documentation())
+
+			// Same as the package details pages.
+			if tn.Alias != nil && tn.Alias.Denoting.TypeName != nil && tn.Alias.Denoting.TypeName.Exported() {
+				continue
+			}
+
+			denoting := tn.Denoting()
+			for _, sel := range buildTypeFieldList(denoting, false) {
+				if sel.Depth == 0 {
+					add(SearchKind_Field, pkg, tn.Name()+"."+sel.Name(), anchor, sel.Field.Documentation())
+				}
+			}
+			for _, sel := range buildTypeMethodsList(denoting, false) {
+				if sel.Depth == 0 {
+					add(SearchKind_Method, pkg, tn.Name()+"."+sel.Name(), anchor, sel.Method.Documentation())
+				}
+			}
+		}
+		for _, c := range pkg.AllConstants {
+			if c.Exported() && !(hideTestOnlys && c.TestOnly()) {
+				add(SearchKind_Constant, pkg, c.Name(), "name-"+c.Name(), c.Documentation())
+			}
+		}
+		for _, v := range pkg.AllVariables {
+			if v.Exported() && !(hideTestOnlys && v.TestOnly()) {
+				add(SearchKind_Variable, pkg, v.Name(), "name-"+v.Name(), v.Documentation())
+			}
+		}
+		for _, f := range pkg.AllFunctions {
+			if f.Exported() && !f.IsMethod() && !(hideTestOnlys && f.TestOnly()) {
+				add(SearchKind_Function, pkg, f.Name(), "name-"+f.Name(), f.Documentation())
+			}
+		}
+	}
+
+	return index
+}
+
+// The matching rules must be kept consistent with the ones
+// in the JavaScript code used in the static search page.
+//
+// Each word in the query must be matched by the name or the docs
+// of an entry. Name matches have higher scores than docs matches.
+func (index *searchIndex) search(query string) []*searchEntry {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil
+	}
+
+	type result struct {
+		entry *searchEntry
+		score int
+	}
+	var results []result
+
+Next:
+	for _, e := range index.entries {
+		score := 0
+		for _, w := range words {
+			switch {
+			case e.matchName == w:
+				score += 100
+			case strings.HasPrefix(e.matchName, w):
+				score += 50
+			case strings.Contains(e.qualified, w):
+				score += 20
+			case strings.Contains(e.lowerDoc, w):
+				score += 5
+			default:
+				continue Next
+			}
+		}
+		results = append(results, result{e, score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if len(a.entry.qualified) != len(b.entry.qualified) {
+			return len(a.entry.qualified) < len(b.entry.qualified)
+		}
+		return a.entry.qualified < b.entry.qualified
+	})
+
+	if len(results) > maxNumSearchResults {
+		results = results[:maxNumSearchResults]
+	}
+	entries := make([]*searchEntry, len(results))
+	for i, r := range results {
+		entries[i] = r.entry
+	}
+	return entries
+}
+
+// ds should be locked before calling this method.
+func (ds *docServer) theSearchIndex() *searchIndex {
+	if ds.searchIndex == nil {
+		ds.searchIndex = buildSearchIndex(ds.analyzer)
+	}
+	return ds.searchIndex
+}
+
+// The href of the page (and the anchor) showing the entry.
+func (e *searchEntry) href(currentPageInfo pagePathInfo) string {
+	href := buildPageHref(currentPageInfo, pagePathInfo{ResTypePackage, e.pkg.Path()}, nil, "")
+	if e.anchor != "" {
+		href += "#" + e.anchor
+	}
+	return href
+}
+
+func (e *searchEntry) displayName() string {
+	if e.name == "" {
+		return e.pkg.Path()
+	}
+	return e.pkg.Path() + "." + e.name
+}
+
+type SearchResult struct {
+	Kind     string
+	Package  string
+	Name     string `json:",omitempty"`
+	Synopsis string `json:",omitempty"`
+	URL      string
+}
+
+func (ds *docServer) searchAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		w.Write([]byte("[]"))
+		return
+	}
+
+	entries := ds.theSearchIndex().search(r.FormValue("q"))
+	results := make([]SearchResult, len(entries))
+	for i, e := range entries {
+		results[i] = SearchResult{
+			Kind:     e.kind,
+			Package:  e.pkg.Path(),
+			Name:     e.name,
+			Synopsis: e.Synopsis(),
+			URL:      e.href(pagePathInfo{ResTypeNone, ""}),
+		}
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// The prebuilt search index used in the static search page in generation mode.
+// Each entry is an array: [kind, displayName, matchName, href, synopsis, doc].
+func (ds *docServer) searchIndexJavascriptFile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
+
+	pageKey := pageCacheKey{
+		resType: ResTypeJS,
+		res:     "search-index",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildSearchIndexJavascriptFile(w)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+func (ds *docServer) buildSearchIndexJavascriptFile(w http.ResponseWriter) []byte {
+	page := NewHtmlPage(goldsVersion, "", ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeJS, "search-index"})
+	searchPageInfo := pagePathInfo{ResTypeNone, "search"}
+
+	page.WriteString("var searchIndex = [\n")
+	for _, e := range ds.theSearchIndex().entries {
+		data, err := json.Marshal([]string{e.kind, e.displayName(), e.matchName, e.href(searchPageInfo), e.Synopsis(), e.doc})
+		if err != nil {
+			panic("should not")
+		}
+		page.Write(data)
+		page.WriteString(",\n")
+	}
+	page.WriteString("];\n")
+
+	return page.Done(w)
+}
@@ -67,6 +67,7 @@ func (ds *docServer) startUpdatingGold() {
 func (ds *docServer) updateAPI(w http.ResponseWriter, r *http.Request) {
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	ds.confirmUpdateTip()
 
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 
+	"go101.org/golds/code"
 	"go101.org/golds/internal/server/translations"
 )
 
@@ -23,6 +24,29 @@ type PageOutputOptions struct {
 	WdPkgsListingManner string
 	FooterShowingManner string
 
+	// Not a page output option. But it changes the content of pages.
+	IncludeTests bool
+
+	// The name of the theme used in docs generation mode.
+	// It is also the initial theme in docs serving mode.
+	Theme string
+
+	// A CSS file used as a custom theme.
+	ThemeFile string
+
+	// The docs for these platforms are merged. The first one is the
+	// primary one. The current platform is used if it is blank.
+	Platforms []code.Platform
+
+	// The rules to check package imports against. Nil means
+	// no checks. Violations are shown in overview and package
+	// details pages.
+	ImportRules []code.ImportRule
+
+	// Not page output options. For docs serving mode only.
+	WatchSourceChanges bool
+	CachePagesOnDisk   bool
+
 	// ToDo:
 	//ListUnexportedRes   bool
 }
@@ -39,6 +63,13 @@ var (
 	//emphasizeWDPackages    = false // list packages in the current directory before other packages
 	wdPkgsListingManner = WdPkgsListingManner_general
 	footerShowingManner = FooterShowingManner_none
+	footerGOOS          = build.Default.GOOS // the primary platform
+	footerGOARCH        = build.Default.GOARCH
+
+	// For the setting switchers in page headers. Set in initSettings.
+	allThemeNames          []string
+	allTranslationNames    []string
+	allTranslationLangTags []string
 
 	// ToDo: use this one to replace the above ones.
 	pageOutputOptions PageOutputOptions
@@ -52,6 +83,9 @@ func setPageOutputOptions(options PageOutputOptions, forTesting bool) {
 	//emphasizeWDPackages = options.EmphasizeWDPkgs || forTesting
 	wdPkgsListingManner = options.WdPkgsListingManner
 	footerShowingManner = options.FooterShowingManner
+	if len(options.Platforms) > 0 {
+		footerGOOS, footerGOARCH = options.Platforms[0].GOOS, options.Platforms[0].GOARCH
+	}
 }
 
 const (
@@ -74,8 +108,10 @@ const (
 	ResTypePackage        pageResType = "pkg"
 	ResTypeDependency     pageResType = "dep"
 	ResTypeImplementation pageResType = "imp"
+	ResTypeHierarchy      pageResType = "hie"
 	ResTypeSource         pageResType = "src"
 	ResTypeReference      pageResType = "use"
+	ResTypeCall           pageResType = "cal"
 	ResTypeCSS            pageResType = "css"
 	ResTypeJS             pageResType = "jvs"
 	ResTypeSVG            pageResType = "svg"
@@ -93,8 +129,10 @@ func isHTMLPage(res pageResType) bool {
 	case ResTypePackage:
 	case ResTypeDependency:
 	case ResTypeImplementation:
+	case ResTypeHierarchy:
 	case ResTypeSource:
 	case ResTypeReference:
+	case ResTypeCall:
 	}
 	return true
 }
@@ -103,6 +141,10 @@ type pageCacheKey struct {
 	resType pageResType
 	res     interface{}
 	options interface{}
+
+	// Set in cachePage and cachedPage automatically.
+	theme       Theme
+	translation Translation
 }
 
 type pageCacheValue struct {
@@ -110,16 +152,19 @@ type pageCacheValue struct {
 	options interface{}
 }
 
+// Pages are cached per theme and translation.
 func (ds *docServer) cachePage(key pageCacheKey, data []byte) {
+	key.theme, key.translation = ds.currentTheme, ds.currentTranslation
 	if genDocsMode {
 	} else if data == nil {
 		delete(ds.cachedPages, key)
-	} else {
+	} else if !ds.snapshotting {
 		ds.cachedPages[key] = data
 	}
 }
 
 func (ds *docServer) cachedPage(key pageCacheKey) (data []byte, ok bool) {
+	key.theme, key.translation = ds.currentTheme, ds.currentTranslation
 	if genDocsMode {
 	} else {
 		data, ok = ds.cachedPages[key]
@@ -200,11 +245,39 @@ func NewHtmlPage(goldsVersion, title string, theme Theme, translation Translatio
 			buildPageHref(currentPageInfo, pagePathInfo{ResTypeCSS, addVersionToFilename(theme.Name(), goldsVersion)}, nil, ""),
 			buildPageHref(currentPageInfo, pagePathInfo{ResTypeJS, addVersionToFilename("golds", goldsVersion)}, nil, ""),
 		)
+
+		// Settings can't be changed in docs generation mode.
+		if !genDocsMode && (len(allThemeNames) > 1 || len(allTranslationNames) > 1) {
+			page.writeSettingSwitchers(theme.Name())
+		}
 	}
 
 	return &page
 }
 
+func (page *htmlPage) writeSettingSwitchers(currentTheme string) {
+	writeSwitcher := func(title, param string, values, texts []string, current string) {
+		page.WriteString(title)
+		page.WriteString(page.translation.Text_Colon(true))
+		for i, value := range values {
+			if i > 0 {
+				page.WriteString(" | ")
+			}
+			if value == current {
+				page.WriteString(texts[i])
+			} else {
+				fmt.Fprintf(page, `<a href="?%s=%s">%s</a>`, param, value, texts[i])
+			}
+		}
+	}
+
+	page.WriteString(`<div id="theme-switcher">`)
+	writeSwitcher(page.translation.Text_Theme(), "theme", allThemeNames, allThemeNames, currentTheme)
+	page.WriteString(" &nbsp; ")
+	writeSwitcher(page.translation.Text_Language(), "lang", allTranslationLangTags, allTranslationNames, page.translation.LangTag())
+	page.WriteString("</div>\n")
+}
+
 // ToDo: w is not used now. It will be used if the page cache feature is remvoed later.s
 func (page *htmlPage) Done(w io.Writer) []byte {
 	if page.isHTML {
@@ -216,7 +289,7 @@ func (page *htmlPage) Done(w io.Writer) []byte {
 			page.WriteString(`<pre id="footer">`)
 			page.WriteByte('\n')
 			if footerShowingManner == FooterShowingManner_simple {
-				footer = page.translation.Text_GeneratedPageFooterSimple(goldsVersion, build.Default.GOOS, build.Default.GOARCH)
+				footer = page.translation.Text_GeneratedPageFooterSimple(goldsVersion, footerGOOS, footerGOARCH)
 			} else { // FooterShowingManner_verbose, FooterShowingManner_verbose_and_qrcode
 				var qrImgLink string
 				if footerShowingManner == FooterShowingManner_verbose_and_qrcode {
@@ -227,7 +300,7 @@ func (page *htmlPage) Done(w io.Writer) []byte {
 						qrImgLink = buildPageHref(page.PathInfo, pagePathInfo{ResTypePNG, "go101-twitter"}, nil, "")
 					}
 				}
-				footer = page.translation.Text_GeneratedPageFooter(goldsVersion, qrImgLink, build.Default.GOOS, build.Default.GOARCH)
+				footer = page.translation.Text_GeneratedPageFooter(goldsVersion, qrImgLink, footerGOOS, footerGOARCH)
 			}
 			page.WriteString(footer)
 			page.WriteString(`</pre>`)
@@ -16,6 +16,7 @@ func (ds *docServer) cssFile(w http.ResponseWriter, r *http.Request, themeName s
 
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	options := struct {
 		Colon string
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) deprecatedUsesPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "deprecated",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildDeprecatedUsesPage(w, ds.buildDeprecatedUsesData())
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type DeprecatedUses struct {
+	Package *code.Package
+	Uses    []code.DeprecatedUse
+}
+
+// Only the packages in the working directory are checked.
+func (ds *docServer) buildDeprecatedUsesData() []DeprecatedUses {
+	var result []DeprecatedUses
+	for i, n := 0, ds.analyzer.NumPackages(); i < n; i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		if !ds.inWorkingDirectory(pkg.Directory) {
+			continue
+		}
+		if uses := ds.analyzer.DeprecatedUses(pkg); len(uses) > 0 {
+			result = append(result, DeprecatedUses{Package: pkg, Uses: uses})
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Package.Path() < result[b].Package.Path()
+	})
+	return result
+}
+
+func (ds *docServer) buildDeprecatedUsesPage(w http.ResponseWriter, deprecatedUses []DeprecatedUses) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_DeprecatedAPIUses(), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeNone, "deprecated"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		page.Translation().Text_DeprecatedAPIUses(),
+	)
+
+	page.WriteString("<pre><code>")
+	page.WriteString(page.Translation().Text_DeprecatedAPIUsesNote())
+	page.WriteString("\n")
+
+	if len(deprecatedUses) == 0 {
+		page.WriteString("\n\t")
+		page.WriteString(page.Translation().Text_BlankList())
+		page.WriteString("\n")
+	}
+
+	for _, du := range deprecatedUses {
+		page.WriteString("\n")
+		page.WriteString(`<span class="title">`)
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, du.Package.Path()}, page, du.Package.Path())
+		page.WriteString(page.Translation().Text_EnclosedInOarentheses(fmt.Sprint(len(du.Uses))))
+		page.WriteString(`</span>`)
+		for _, use := range du.Uses {
+			page.WriteString("\n\t")
+			pos := du.Package.PPkg.Fset.PositionFor(use.AstIdent.NamePos, false)
+			writeSrouceCodeLineLink(page, du.Package, pos, fmt.Sprintf("%s#L%d", use.FileInfo.AstBareFileName(), pos.Line), "")
+			page.WriteString(": ")
+			ds.writeDeprecatedObject(page, use)
+		}
+		page.WriteString("\n")
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+// Links to the declaration of the deprecated object, if it is available.
+func (ds *docServer) writeDeprecatedObject(page *htmlPage, use code.DeprecatedUse) {
+	objPkgPath := use.Object.Pkg().Path()
+	page.WriteString(objPkgPath)
+	page.WriteByte('.')
+	if pkg := ds.analyzer.PackageByPath(objPkgPath); pkg != nil && use.Object.Pos().IsValid() {
+		writeSrouceCodeLineLink(page, pkg, pkg.PPkg.Fset.PositionFor(use.Object.Pos(), false), use.Name, "")
+	} else {
+		page.WriteString(use.Name)
+	}
+}
+
+// Listed in the overview page.
+func (ds *docServer) writeDeprecatedUsesBlock(page *htmlPage) {
+	var count int
+	for _, du := range ds.buildDeprecatedUsesData() {
+		count += len(du.Uses)
+	}
+	var detailsLink string
+	if count > 0 {
+		detailsLink = buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "deprecated"}, nil, "")
+	}
+	fmt.Fprintf(page, `
+<pre><code><span class="title">%s</span></code></pre>`,
+		page.Translation().Text_DeprecatedAPIUsesWithDetailsLink(count, detailsLink),
+	)
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"net/http"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) functionCallsPage(w http.ResponseWriter, r *http.Request, pkgPath, funcName string) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeCall,
+		res:     [...]string{pkgPath, funcName},
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		result, err := ds.buildFunctionCallsData(pkgPath, funcName)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "Build calls info for (", funcName, ") in ", pkgPath, " error: ", err)
+			return
+		}
+
+		data = ds.buildFunctionCallsPage(w, result)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type FunctionCallsResult struct {
+	Package  *code.Package
+	Func     *types.Func
+	FuncName string // "F" or "T.M"
+
+	Callers []FunctionCallGroup
+	Callees []FunctionCallGroup
+}
+
+// The calls between two functions, grouped by
+// call sites in the same caller (or to the same callee).
+type FunctionCallGroup struct {
+	Func  *types.Func // the caller or the callee
+	Via   *types.Func // the interface method, for calls through interface dispatch
+	Calls []code.FunctionCall
+
+	// For callees which are interface methods.
+	Implementations []*types.Func
+}
+
+// funcName is "F" for functions and "T.M" for methods.
+func (ds *docServer) buildFunctionCallsData(pkgPath, funcName string) (*FunctionCallsResult, error) {
+	pkg := ds.analyzer.PackageByPath(pkgPath)
+	if pkg == nil {
+		return nil, errors.New("package not found")
+	}
+
+	scope := pkg.PPkg.Types.Scope()
+	var f *types.Func
+	if i := strings.IndexByte(funcName, '.'); i < 0 {
+		f, _ = scope.Lookup(funcName).(*types.Func)
+	} else if tn, ok := scope.Lookup(funcName[:i]).(*types.TypeName); ok {
+		obj, _, _ := types.LookupFieldOrMethod(tn.Type(), true, pkg.PPkg.Types, funcName[i+1:])
+		f, _ = obj.(*types.Func)
+	}
+	if f == nil {
+		return nil, errors.New("function not found")
+	}
+
+	result := &FunctionCallsResult{
+		Package:  pkg,
+		Func:     f,
+		FuncName: funcName,
+	}
+
+	// The calls are sorted by call sites, so the
+	// groups are ordered by their first call sites.
+	var groupCalls = func(calls []code.FunctionCall, byCaller bool) []FunctionCallGroup {
+		var groups []FunctionCallGroup
+		var indexes = make(map[[2]*types.Func]int, len(calls))
+		for _, call := range calls {
+			key := [2]*types.Func{call.Callee, call.Via}
+			if byCaller {
+				key[0] = call.Caller
+			} else if call.Via != nil {
+				// Implementations are listed in the interface method groups.
+				continue
+			}
+			i, ok := indexes[key]
+			if !ok {
+				i = len(groups)
+				indexes[key] = i
+				groups = append(groups, FunctionCallGroup{Func: key[0], Via: key[1]})
+			}
+			groups[i].Calls = append(groups[i].Calls, call)
+		}
+		return groups
+	}
+
+	result.Callers = groupCalls(ds.analyzer.FunctionCallers(f), true)
+	result.Callees = groupCalls(ds.analyzer.FunctionCallees(f), false)
+	for i := range result.Callees {
+		g := &result.Callees[i]
+		var listed = make(map[*types.Func]bool)
+		for _, call := range ds.analyzer.FunctionCallees(f) {
+			if call.Via == g.Func && !listed[call.Callee] {
+				listed[call.Callee] = true
+				g.Implementations = append(g.Implementations, call.Callee)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (ds *docServer) buildFunctionCallsPage(w http.ResponseWriter, result *FunctionCallsResult) []byte {
+	qualifiedName := result.Package.Path() + "." + result.FuncName
+	title := ds.currentTranslation.Text_FunctionCalls() + ds.currentTranslation.Text_Colon(true) + qualifiedName
+	page := NewHtmlPage(goldsVersion, title, ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeCall, result.Package.Path() + ".." + result.FuncName})
+
+	// The uses of the function, not only the calls, are listed in its references page.
+	page.WriteString(`<pre><code><span style="font-size:x-large;">`)
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeReference, result.Package.Path() + ".." + result.FuncName}, page, "func")
+	fmt.Fprintf(page, ` <a href="%s">%s</a>.<b>`,
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, result.Package.Path()}, nil, ""),
+		result.Package.Path(),
+	)
+	if pkg := ds.analyzer.PackageByPath(result.Func.Pkg().Path()); pkg != nil && result.Func.Pos().IsValid() {
+		pos := pkg.PPkg.Fset.PositionFor(result.Func.Pos(), false)
+		writeSrouceCodeLineLink(page, pkg, pos, result.FuncName, "")
+	} else {
+		page.WriteString(result.FuncName)
+	}
+	page.WriteString("</b></span>\n")
+
+	fmt.Fprintf(page, "\n<span class=\"title\" id=\"callers\">%s</span>", page.Translation().Text_Callers(len(result.Callers)))
+	if len(result.Callers) == 0 {
+		page.WriteString("\n\t")
+		page.WriteString(page.Translation().Text_BlankList())
+	}
+	for _, g := range result.Callers {
+		page.WriteString("\n\t")
+		ds.writeFunctionCallsLink(page, result.Package, g.Func)
+		if g.Via != nil {
+			page.WriteString(" <i>")
+			page.WriteString(page.Translation().Text_ThroughInterfaceMethod(ds.functionQualifiedName(g.Via, result.Package)))
+			page.WriteString("</i>")
+		}
+		page.WriteString(": ")
+		writeFunctionCallSites(page, g.Calls)
+	}
+	page.WriteString("\n")
+
+	fmt.Fprintf(page, "\n<span class=\"title\" id=\"callees\">%s</span>", page.Translation().Text_Callees(len(result.Callees)))
+	if len(result.Callees) == 0 {
+		page.WriteString("\n\t")
+		page.WriteString(page.Translation().Text_BlankList())
+	}
+	for _, g := range result.Callees {
+		page.WriteString("\n\t")
+		ds.writeFunctionCallsLink(page, result.Package, g.Func)
+		page.WriteString(": ")
+		writeFunctionCallSites(page, g.Calls)
+		for _, impl := range g.Implementations {
+			page.WriteString("\n\t\t")
+			ds.writeFunctionCallsLink(page, result.Package, impl)
+		}
+	}
+	page.WriteString("\n")
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+// Such as "io.Reader.Read". The package path is omitted for the functions in pkg.
+func (ds *docServer) functionQualifiedName(f *types.Func, pkg *code.Package) string {
+	name := code.FunctionPathName(f)
+	if name == "" {
+		name = f.Name()
+	}
+	if f.Pkg() == nil || f.Pkg().Path() == pkg.Path() {
+		return name
+	}
+	return f.Pkg().Path() + "." + name
+}
+
+// Links to the calls page of a function, if it is available.
+func (ds *docServer) writeFunctionCallsLink(page *htmlPage, pkg *code.Package, f *types.Func) {
+	name := code.FunctionPathName(f)
+	if f.Pkg() != nil && f.Pkg().Path() != pkg.Path() {
+		page.WriteString(f.Pkg().Path())
+		page.WriteByte('.')
+	}
+	if name == "" || f.Pkg() == nil || ds.analyzer.PackageByPath(f.Pkg().Path()) == nil {
+		page.WriteString(f.Name())
+		return
+	}
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeCall, f.Pkg().Path() + ".." + name}, page, name)
+}
+
+func writeFunctionCallSites(page *htmlPage, calls []code.FunctionCall) {
+	var fileInfo *code.SourceFileInfo
+	for i, call := range calls {
+		if i > 0 {
+			page.WriteString(", ")
+		}
+		pkg := call.FileInfo.Pkg
+		pos := pkg.PPkg.Fset.PositionFor(call.AstIdent.NamePos, false)
+		if fileInfo == call.FileInfo {
+			writeSrouceCodeLineLink(page, pkg, pos, fmt.Sprintf("#L%d", pos.Line), "")
+		} else {
+			fileInfo = call.FileInfo
+			writeSrouceCodeLineLink(page, pkg, pos, fmt.Sprintf("%s#L%d", fileInfo.AstBareFileName(), pos.Line), "")
+		}
+	}
+}
@@ -48,6 +48,7 @@ func (ds *docServer) identifierReferencePage(w http.ResponseWriter, r *http.Requ
 
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -122,7 +123,7 @@ func (ds *docServer) buildReferencesPage(w http.ResponseWriter, result *Referenc
 				methodPkgPath = result.Selector.Method.Pkg.Path()
 			}
 			var link string
-			if ds.analyzer.CheckTypeMethodContributingToTypeImplementations(result.Package.Path(), result.Resource.Name(), methodPkgPath, methodName) {
+			if _, ok := result.Resource.(*code.TypeName); ok && ds.analyzer.CheckTypeMethodContributingToTypeImplementations(result.Package.Path(), result.Resource.Name(), methodPkgPath, methodName) {
 				anchorName := methodName
 				if !token.IsExported(methodName) {
 					anchorName = methodPkgPath + "." + anchorName
@@ -163,11 +164,23 @@ func (ds *docServer) buildReferencesPage(w http.ResponseWriter, result *Referenc
 	}
 	fmt.Fprintf(page, `</b></span>%s`, suffix)
 
+	if note := ds.analyzer.ObjectDeprecation(result.Object); note != "" {
+		var numDeprecatedUses int
+		for _, ref := range result.References {
+			if ref.Pkg.Path() != result.Object.Pkg().Path() {
+				numDeprecatedUses += len(ref.Identifiers)
+			}
+		}
+		fmt.Fprintf(page, "\n\n<i>%s</i>\n", page.Translation().Text_DeprecatedUses(numDeprecatedUses))
+		writePageText(page, "\t", note, true)
+	}
+
 	fmt.Fprintf(page, "\n\n%s:\n", page.Translation().Text_ObjectUses(result.UsesCount))
 
 	type idpos struct {
 		id  *ast.Ident
 		pos token.Position
+		len int
 	}
 	stack := make([]idpos, 0, 8)
 
@@ -189,7 +202,16 @@ func (ds *docServer) buildReferencesPage(w http.ResponseWriter, result *Referenc
 		}
 
 		for i := range stack {
-			endOffset := stack[i].pos.Offset + len(stack[i].id.Name)
+			if stack[i].pos.Offset < start {
+				// Implicit uses might overlap, such as
+				// the elements of nested unkeyed struct literals.
+				continue
+			}
+			endOffset := stack[i].pos.Offset + stack[i].len
+			if endOffset > end {
+				// Implicit uses might span lines.
+				endOffset = end
+			}
 
 			//page.Write(fileInfo.Content[start:stack[i].pos.Offset])
 			WriteHtmlEscapedBytes(page, fileInfo.Content[start:stack[i].pos.Offset])
@@ -251,7 +273,11 @@ func (ds *docServer) buildReferencesPage(w http.ResponseWriter, result *Referenc
 				page.WriteString(": ")
 				lineNumber = pos.Line
 			}
-			stack = append(stack, idpos{id: id.AstIdent, pos: pos})
+			length := len(id.AstIdent.Name)
+			if id.Implicit != nil {
+				length = int(id.Implicit.End() - id.Implicit.Pos())
+			}
+			stack = append(stack, idpos{id: id.AstIdent, pos: pos, len: length})
 		}
 		excerptCode(fileInfo)
 	}
@@ -283,6 +309,7 @@ type ReferencesResult struct {
 	Identifier string
 	Resource   code.Resource
 	Selector   *code.Selector // non-nil for fields and methods
+	Object     types.Object
 	References []*ObjectReferences
 	UsesCount  int
 }
@@ -349,36 +376,51 @@ func (ds *docServer) buildReferencesData(pkgPath, identifier string) (*Reference
 
 		return nil, fmt.Errorf("type %s is not found in package %s", tokens[0], pkgPath)
 	} else { // len(tokens) == 2
+		var t *code.TypeInfo
 		for _, tn := range pkg.AllTypeNames {
 			if tn.Name() == tokens[0] {
-				t := tn.Denoting()
-				for _, field := range t.AllFields {
-					if field.Name() == tokens[1] {
-						sel = field
-						goto SelFound
-					}
-				}
-				for _, method := range t.AllMethods {
-					if method.Name() == tokens[1] {
-						sel = method
-						goto SelFound
+				res, t = tn, tn.Denoting()
+				goto LookForSel
+			}
+		}
+		// Package-level variables of unnamed struct types are used
+		// as fake type names to denote the fields of the struct types.
+		for _, v := range pkg.AllVariables {
+			if v.Name() == tokens[0] {
+				if _, ok := types.Unalias(v.Var.Type()).(*types.Struct); ok {
+					if t = ds.analyzer.TryRegisteringType(v.Var.Type(), false); t != nil {
+						res = v
+						goto LookForSel
 					}
 				}
-				return nil, fmt.Errorf("selector %s is not found for type %s in package %s", tokens[1], tokens[0], pkgPath)
-			SelFound:
-
-				res, obj = tn, sel.Object()
-				goto ResFound
+				return nil, fmt.Errorf("variable %s in package %s is not of an unnamed struct type", tokens[0], pkgPath)
 			}
 		}
 		return nil, fmt.Errorf("type %s is not found in package %s", tokens[0], pkgPath)
+
+	LookForSel:
+		for _, field := range t.AllFields {
+			if field.Name() == tokens[1] {
+				sel = field
+				goto SelFound
+			}
+		}
+		for _, method := range t.AllMethods {
+			if method.Name() == tokens[1] {
+				sel = method
+				goto SelFound
+			}
+		}
+		return nil, fmt.Errorf("selector %s is not found for %s in package %s", tokens[1], tokens[0], pkgPath)
+	SelFound:
+
+		obj = sel.Object()
 	}
 ResFound:
 
 	var refs []*ObjectReferences
 	var usesCount int
-	if obj != nil {
-		ids := ds.analyzer.ObjectReferences(obj)
+	if ids := ds.analyzer.ObjectReferences(obj); len(ids) > 0 {
 		usesCount = len(ids)
 
 		numPkgs := 0
@@ -476,6 +518,7 @@ ResFound:
 		Identifier: identifier,
 		Resource:   res,
 		Selector:   sel,
+		Object:     obj,
 		References: refs,
 		UsesCount:  usesCount,
 	}, nil
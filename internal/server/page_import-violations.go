@@ -0,0 +1,102 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) importViolationsPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "import-violations",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildImportViolationsPage(w, ds.analyzer.ImportViolations())
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+func (ds *docServer) buildImportViolationsPage(w http.ResponseWriter, violations []code.ImportViolation) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_ImportViolations(), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeNone, "import-violations"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		page.Translation().Text_ImportViolations(),
+	)
+
+	page.WriteString("<pre><code>")
+	if len(violations) == 0 {
+		page.WriteString("\t")
+		page.WriteString(page.Translation().Text_BlankList())
+		page.WriteString("\n")
+	}
+
+	var lastImporter *code.Package
+	for _, v := range violations {
+		if v.Importer != lastImporter {
+			if lastImporter != nil {
+				page.WriteString("\n")
+			}
+			lastImporter = v.Importer
+			page.WriteString("\n")
+			page.WriteString(`<span class="title">`)
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, v.Importer.Path()}, page, v.Importer.Path())
+			page.WriteString(`</span>`)
+		}
+		writeImportViolation(page, v)
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+// Also used in package details pages.
+func writeImportViolation(page *htmlPage, v code.ImportViolation) {
+	page.WriteString("\n\t")
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, v.Imported.Path()}, page, v.Imported.Path())
+	fmt.Fprintf(page, " <i>%s</i>", page.Translation().Text_ForbiddenByImportRule(v.Rule.Line, v.Rule.String()))
+}
+
+func (ds *docServer) writeImportViolationsBlock(page *htmlPage) {
+	if !ds.analyzer.ImportRulesChecked() {
+		return
+	}
+	fmt.Fprintf(page, `
+<pre><code><span class="title">%s</span></code></pre>`,
+		page.Translation().Text_ImportViolationsWithDetailsLink(
+			len(ds.analyzer.ImportViolations()),
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "import-violations"}, nil, ""),
+		),
+	)
+}
+
+// Used in docs generation mode, so that CI jobs fail on violations.
+func (ds *docServer) exitOnImportViolations() {
+	violations := ds.analyzer.ImportViolations()
+	if len(violations) == 0 {
+		return
+	}
+	for _, v := range violations {
+		log.Printf("%s imports %s (forbidden by rule at line %d: %s)", v.Importer.Path(), v.Imported.Path(), v.Rule.Line, v.Rule)
+	}
+	log.Printf("%d imports violate the import rules.", len(violations))
+	os.Exit(1)
+}
@@ -6,11 +6,70 @@ import (
 
 func (ds *docServer) javascriptFile(w http.ResponseWriter, r *http.Request, themeName string) {
 	w.Header().Set("Content-Type", "application/javascript")
-	w.Write(jsFile)
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
+
+	pageKey := pageCacheKey{
+		resType: ResTypeJS,
+		res:     themeName,
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		// In generation mode, the file is written by page.Done.
+		page := NewHtmlPage(goldsVersion, "", nil, ds.currentTranslation, pagePathInfo{ResTypeJS, themeName})
+		page.Write(jsFile)
+		data = page.Done(w)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
 }
 
 var jsFile = []byte(`
-// ToDo
+// Identifier tooltips in source code pages are shown as richer popups.
+// Without JavaScript, they are shown as the native title tips.
+document.addEventListener("DOMContentLoaded", function() {
+	var code = document.querySelector("pre.line-numbers");
+	if (code == null) {
+		return;
+	}
+	var tip = document.createElement("div");
+	tip.id = "ident-tip";
+	tip.className = "hidden";
+	document.body.appendChild(tip);
+
+	code.addEventListener("mouseover", function(e) {
+		var elem = e.target.closest("[title], [data-tip]");
+		if (elem == null) {
+			return;
+		}
+		// Avoid showing the native tip at the same time.
+		if (elem.hasAttribute("title")) {
+			elem.setAttribute("data-tip", elem.getAttribute("title"));
+			elem.removeAttribute("title");
+		}
+		var lines = elem.getAttribute("data-tip").split("\n");
+		tip.textContent = "";
+		for (var i = 0; i < lines.length; i++) {
+			var line = document.createElement("div");
+			line.textContent = lines[i];
+			if (i == 0) {
+				line.className = "b";
+			}
+			tip.appendChild(line);
+		}
+		var rect = elem.getBoundingClientRect();
+		tip.style.left = (rect.left + window.scrollX) + "px";
+		tip.style.top = (rect.bottom + window.scrollY + 2) + "px";
+		tip.className = "";
+	});
+	code.addEventListener("mouseout", function(e) {
+		if (e.target.closest("[data-tip]") != null) {
+			tip.className = "hidden";
+		}
+	});
+});
 `)
 
 //function updateUpdateTip() {
@@ -22,6 +22,7 @@ func (ds *docServer) methodImplementationPage(w http.ResponseWriter, r *http.Req
 
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -79,6 +80,14 @@ func (ds *docServer) buildImplementationPage(w http.ResponseWriter, result *Meth
 	writeKindText(page, result.TypeName.Denoting().TT)
 	page.WriteString("</span>\n")
 
+	fmt.Fprintf(page, `
+<span class="title">%s</span>
+	`,
+		page.Translation().Text_TypeHierarchy(),
+	)
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeHierarchy, qualifiedTypeName}, page, qualifiedTypeName)
+	page.WriteString("\n")
+
 	nonImplementingMethodCountText := ""
 	if !result.IsInterface {
 		nonImplementingMethodCountText = page.Translation().Text_NumMethodsImplementingNothing(int(result.NonImplementingMethodCount))
@@ -2,12 +2,139 @@ package server
 
 import (
 	"fmt"
+	"html"
 	"net/http"
-)
 
-func (ds *docServer) modulePage(w http.ResponseWriter, r *http.Request, rootVersion string) {
-	// w.WriteHeader(http.StatusTooEarly)
+	"go101.org/golds/code"
+)
 
+func (ds *docServer) modulePage(w http.ResponseWriter, r *http.Request, modulePath string) {
 	w.Header().Set("Content-Type", "text/html")
-	fmt.Fprint(w, "Module page is not implemented yet")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeModule,
+		res:     modulePath,
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		mod := ds.analyzer.ModuleByPath(modulePath)
+		if mod == nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "Module (%s) not found", modulePath)
+			return
+		}
+
+		data = ds.buildModulePage(w, mod)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+func (ds *docServer) buildModulePage(w http.ResponseWriter, mod *code.Module) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_Module(mod.Root), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeModule, mod.Root})
+
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">module <b>%s</b></span>
+`,
+		html.EscapeString(mod.Root),
+	)
+
+	fmt.Fprintf(page, `
+<span class="title">%s</span>
+	%s`,
+		page.Translation().Text_ModulePath(),
+		html.EscapeString(mod.Root),
+	)
+
+	if mod.Version != "" {
+		fmt.Fprintf(page, `
+
+<span class="title">%s</span>
+	%s`,
+			page.Translation().Text_Version(),
+			html.EscapeString(mod.Version),
+		)
+	}
+
+	if mod.Replace != nil {
+		fmt.Fprintf(page, `
+
+<span class="title">%s</span>
+	%s`,
+			page.Translation().Text_ReplacedBy(),
+			html.EscapeString(moduleDisplayName(mod.Replace)),
+		)
+	}
+
+	fmt.Fprintf(page, `
+
+<span class="title">%s</span>
+	%s`,
+		page.Translation().Text_DependencyRelations(""),
+		page.Translation().Text_RequireStat(len(mod.Requires), len(mod.RequiredBys)),
+	)
+
+	if len(mod.Pkgs) > 0 {
+		pkgs := make([]PackageForListing, len(mod.Pkgs))
+		list := make([]*PackageForListing, len(mod.Pkgs))
+		for i, pkg := range mod.Pkgs {
+			list[i] = &pkgs[i]
+
+			list[i].Package = pkg
+			list[i].Mod = mod
+			list[i].Path = pkg.Path()
+			list[i].Remaining = pkg.Path()
+			list[i].Name = pkg.PPkg.Name
+			list[i].Index = pkg.Index
+		}
+		ImprovePackagesForListing(list)
+
+		fmt.Fprint(page, "\n\n", `<span class="title">`, page.Translation().Text_PackageList(), `</span>`)
+		ds.writePackagesForListing(page, list, false, "")
+	}
+
+	if len(mod.Requires) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, page.Translation().Text_Requires(), `</span>`)
+		writeModulesForListing(page, mod.Requires)
+	}
+
+	if len(mod.RequiredBys) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, page.Translation().Text_RequiredBy(), `</span>`)
+		writeModulesForListing(page, mod.RequiredBys)
+	}
+
+	page.WriteString("</code></pre>")
+
+	return page.Done(w)
+}
+
+func moduleDisplayName(mod *code.Module) string {
+	if mod.Version == "" {
+		return mod.Root
+	}
+	return mod.Root + "@" + mod.Version
+}
+
+func writeModuleLink(page *htmlPage, mod *code.Module) {
+	fmt.Fprintf(page, `<a href="%s">%s</a>`,
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeModule, mod.Root}, nil, ""),
+		html.EscapeString(moduleDisplayName(mod)),
+	)
+}
+
+func writeModulesForListing(page *htmlPage, mods []*code.Module) {
+	for _, mod := range mods {
+		page.WriteString("\n\t")
+		writeModuleLink(page, mod)
+	}
 }
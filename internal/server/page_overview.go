@@ -12,7 +12,7 @@ import (
 )
 
 type overviewPageOptions struct {
-	sortBy string // "alphabet", "importedbys"
+	sortBy string // "alphabet", "importedbys", "modules"
 }
 
 func (ds *docServer) overviewPage(w http.ResponseWriter, r *http.Request) {
@@ -20,6 +20,7 @@ func (ds *docServer) overviewPage(w http.ResponseWriter, r *http.Request) {
 
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	//if ds.phase < Phase_Parsed {
 	if ds.phase < Phase_Analyzed {
@@ -45,6 +46,8 @@ func (ds *docServer) overviewPage(w http.ResponseWriter, r *http.Request) {
 			ds.cachePage(pageKey, nil)
 			pageKey.options = overviewPageOptions{sortBy: "importedbys"}
 			ds.cachePage(pageKey, nil)
+			pageKey.options = overviewPageOptions{sortBy: "modules"}
+			ds.cachePage(pageKey, nil)
 		}
 	}
 
@@ -56,7 +59,7 @@ func (ds *docServer) overviewPage(w http.ResponseWriter, r *http.Request) {
 
 	var sortBy = r.FormValue("sortby")
 	switch sortBy {
-	case "alphabet", "importedbys":
+	case "alphabet", "importedbys", "modules":
 	default:
 		if ok {
 			sortBy = oldOptions.sortBy
@@ -101,8 +104,16 @@ func (ds *docServer) buildOverviewPage(w http.ResponseWriter, overview *Overview
 		ds.writeUpdateGoldBlock(page)
 	}
 
+	ds.writeSearchForm(page, "")
+
 	ds.writeSimpleStatsBlock(page, &overview.Stats)
 
+	ds.writeImportViolationsBlock(page)
+
+	ds.writeDeprecatedUsesBlock(page)
+
+	ds.writeModulesBlock(page)
+
 	page.WriteString("<pre>")
 
 	if genDocsMode {
@@ -112,23 +123,49 @@ func (ds *docServer) buildOverviewPage(w http.ResponseWriter, overview *Overview
 	} else {
 		var textSortByAlphabet = page.Translation().Text_SortByItem("alphabet")
 		var textSortByImportedBys = page.Translation().Text_SortByItem("importedbys")
+		var textGroupByModules = page.Translation().Text_SortByItem("modules")
 
-		switch sortBy {
-		case "alphabet":
-			textSortByImportedBys = fmt.Sprintf(`<a href="%s">%s</a>`, "?sortby=importedbys", textSortByImportedBys)
-		case "importedbys":
+		if sortBy != "alphabet" {
 			textSortByAlphabet = fmt.Sprintf(`<a href="%s">%s</a>`, "?sortby=alphabet", textSortByAlphabet)
 		}
+		if sortBy != "importedbys" {
+			textSortByImportedBys = fmt.Sprintf(`<a href="%s">%s</a>`, "?sortby=importedbys", textSortByImportedBys)
+		}
+		if sortBy != "modules" {
+			textGroupByModules = fmt.Sprintf(`<a href="%s">%s</a>`, "?sortby=modules", textGroupByModules)
+		}
 
-		fmt.Fprintf(page, `<code><span class="title">%s (%s%s | %s)</span></code>`,
+		fmt.Fprintf(page, `<code><span class="title">%s (%s%s | %s | %s)</span></code>`,
 			page.Translation().Text_PackageList(),
 			page.Translation().Text_SortBy(),
 			textSortByAlphabet,
 			textSortByImportedBys,
+			textGroupByModules,
 		)
 	}
 
-	ds.writePackagesForListing(page, overview.Packages, true, sortBy)
+	if sortBy == "modules" {
+		// The packages are grouped by modules.
+		for pkgs := overview.Packages; len(pkgs) > 0; {
+			mod, n := pkgs[0].Mod, 1
+			for n < len(pkgs) && pkgs[n].Mod == mod {
+				n++
+			}
+			page.WriteString("\n\n<code>")
+			page.WriteString(page.Translation().Text_BelongingModule())
+			page.WriteString(": ")
+			if mod == nil {
+				page.WriteString(page.Translation().Text_BlankList())
+			} else {
+				writeModuleLink(page, mod)
+			}
+			page.WriteString("</code>")
+			ds.writePackagesForListing(page, pkgs[:n], true, "alphabet")
+			pkgs = pkgs[n:]
+		}
+	} else {
+		ds.writePackagesForListing(page, overview.Packages, true, sortBy)
+	}
 
 	page.WriteString("</pre>")
 
@@ -244,6 +281,34 @@ func (ds *docServer) writeUpdateGoldBlock(page *htmlPage) {
 	)
 }
 
+// The block is only shown when non-std modules are involved.
+func (ds *docServer) writeModulesBlock(page *htmlPage) {
+	var mods []*code.Module
+	for i, n := 0, ds.analyzer.NumModules(); i < n; i++ {
+		if mod := ds.analyzer.ModuleAt(i); len(mod.Pkgs) > 0 {
+			mods = append(mods, mod)
+		}
+	}
+	if len(mods) == 0 || len(mods) == 1 && ds.analyzer.IsStandardModule(mods[0]) {
+		return
+	}
+
+	fmt.Fprintf(page, `
+<pre><code><span class="title">%s</span></code>`,
+		page.Translation().Text_Modules(),
+	)
+	for _, mod := range mods {
+		page.WriteString("\n\t")
+		writeModuleLink(page, mod)
+		if !ds.analyzer.IsStandardModule(mod) {
+			page.WriteString(" <i>(")
+			page.WriteString(page.Translation().Text_RequireStat(len(mod.Requires), len(mod.RequiredBys)))
+			page.WriteString(")</i>")
+		}
+	}
+	page.WriteString("\n</pre>")
+}
+
 func (ds *docServer) writeSimpleStatsBlock(page *htmlPage, stats *code.Stats) {
 	text := page.Translation().Text_SimpleStats(stats)
 	text = strings.Replace(text, "\n", "\n\t", -1)
@@ -316,6 +381,31 @@ func (ds *docServer) buildOverviewData(sortBy string) *Overview {
 			return result[a].Path < result[b].Path
 		})
 		ImprovePackagesForListing(result)
+	case "modules":
+		modIndexes := make(map[*code.Module]int, ds.analyzer.NumModules())
+		for i, n := 0, ds.analyzer.NumModules(); i < n; i++ {
+			modIndexes[ds.analyzer.ModuleAt(i)] = i
+		}
+		modIndex := func(pkg *PackageForListing) int {
+			if pkg.Mod == nil {
+				return len(modIndexes)
+			}
+			return modIndexes[pkg.Mod]
+		}
+		sort.Slice(result, func(a, b int) bool {
+			if m, n := modIndex(result[a]), modIndex(result[b]); m != n {
+				return m < n
+			}
+			return result[a].Path < result[b].Path
+		})
+		for pkgs := result; len(pkgs) > 0; {
+			n := 1
+			for n < len(pkgs) && pkgs[n].Mod == pkgs[0].Mod {
+				n++
+			}
+			ImprovePackagesForListing(pkgs[:n])
+			pkgs = pkgs[n:]
+		}
 	case "importedbys":
 		var pkgs = result
 		for i, pkg := range pkgs {
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
+
+	"go101.org/golds/code"
 )
 
 func (ds *docServer) packageDependenciesPage(w http.ResponseWriter, r *http.Request, pkgPath string) {
@@ -11,6 +15,7 @@ func (ds *docServer) packageDependenciesPage(w http.ResponseWriter, r *http.Requ
 
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -36,6 +41,39 @@ func (ds *docServer) packageDependenciesPage(w http.ResponseWriter, r *http.Requ
 		resType: ResTypeDependency,
 		res:     pkgPath,
 	}
+	oldOptions, ok := ds.cachedPageOptions(pageKey).(dependencyPageOptions)
+
+	var graphDepth = r.FormValue("graphdepth")
+	switch graphDepth {
+	case "1", "2", "3", "0":
+	default:
+		if ok {
+			graphDepth = oldOptions.graphDepth
+		} else {
+			graphDepth = "2"
+		}
+	}
+
+	var graphStd = r.FormValue("graphstd")
+	switch graphStd {
+	case "show", "hide":
+	default:
+		if ok {
+			graphStd = oldOptions.graphStd
+		} else {
+			graphStd = "hide"
+		}
+	}
+
+	newOptions := dependencyPageOptions{
+		graphDepth: graphDepth,
+		graphStd:   graphStd,
+	}
+	if newOptions != oldOptions {
+		ds.cachePageOptions(pageKey, newOptions)
+	}
+
+	pageKey.options = newOptions
 	data, ok := ds.cachedPage(pageKey)
 	if !ok {
 
@@ -46,12 +84,17 @@ func (ds *docServer) packageDependenciesPage(w http.ResponseWriter, r *http.Requ
 			return
 		}
 
-		data = ds.buildPackageDependenciesPage(w, depInfo)
+		data = ds.buildPackageDependenciesPage(w, depInfo, newOptions)
 		ds.cachePage(pageKey, data)
 	}
 	w.Write(data)
 }
 
+type dependencyPageOptions struct {
+	graphDepth string // "1", "2", "3", "0" (no limits)
+	graphStd   string // "show", "hide"
+}
+
 type PackageDependencyInfo struct {
 	Name       string
 	ImportPath string
@@ -59,6 +102,10 @@ type PackageDependencyInfo struct {
 
 	Imports     []*PackageForListing
 	ImportedBys []*PackageForListing
+
+	// Exported identifiers used by the importing packages.
+	Package *code.Package
+	Uses    map[*code.Package][]code.ObjectUses
 }
 
 func (ds *docServer) buildPackageDependenciesData(pkgPath string) *PackageDependencyInfo {
@@ -71,6 +118,8 @@ func (ds *docServer) buildPackageDependenciesData(pkgPath string) *PackageDepend
 		Name:       pkg.PPkg.Name,
 		ImportPath: pkgPath,
 		Index:      pkg.Index,
+		Package:    pkg,
+		Uses:       ds.analyzer.ExportedObjectUses(pkg),
 	}
 
 	imports := make([]PackageForListing, len(pkg.Deps))
@@ -111,7 +160,7 @@ func (ds *docServer) buildPackageDependenciesData(pkgPath string) *PackageDepend
 	return result
 }
 
-func (ds *docServer) buildPackageDependenciesPage(w http.ResponseWriter, depInfo *PackageDependencyInfo) []byte {
+func (ds *docServer) buildPackageDependenciesPage(w http.ResponseWriter, depInfo *PackageDependencyInfo, options dependencyPageOptions) []byte {
 	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_DependencyRelations(depInfo.ImportPath), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeDependency, depInfo.ImportPath})
 
 	fmt.Fprintf(page, `
@@ -128,6 +177,10 @@ func (ds *docServer) buildPackageDependenciesPage(w http.ResponseWriter, depInfo
 		depInfo.ImportPath,
 	)
 
+	if len(depInfo.Imports) > 0 {
+		ds.writeDependencyGraph(page, depInfo, options)
+	}
+
 	if len(depInfo.Imports) > 0 {
 		fmt.Fprint(page, "\n\n", `<span class="title">`, page.Translation().Text_Imports(), `</span>`)
 		ds.writePackagesForListing(page, depInfo.Imports, false, "")
@@ -138,5 +191,100 @@ func (ds *docServer) buildPackageDependenciesPage(w http.ResponseWriter, depInfo
 		ds.writePackagesForListing(page, depInfo.ImportedBys, false, "")
 	}
 
+	if len(depInfo.Uses) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title" id="used-identifiers">`, page.Translation().Text_UsedExportedIdentifiers(), `</span>`)
+		ds.writeExportedObjectUses(page, depInfo)
+	}
+
 	return page.Done(w)
 }
+
+// ToDo: also provide the graph options in generation mode (only the default graph is generated now).
+func (ds *docServer) writeDependencyGraph(page *htmlPage, depInfo *PackageDependencyInfo, options dependencyPageOptions) {
+	depth, _ := strconv.Atoi(options.graphDepth)
+	showStd := options.graphStd == "show"
+	svgHref := buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, dependencyGraphSVGPath(depInfo.ImportPath, depth, showStd)}, nil, "")
+
+	page.WriteString("\n\n")
+	if genDocsMode {
+		fmt.Fprintf(page, `<span class="title" id="dependency-graph">%s</span>`, page.Translation().Text_DependencyGraph())
+	} else {
+		var depthLinks = make([]string, 0, 4)
+		for _, d := range []string{"1", "2", "3", "0"} {
+			text := d
+			if d == "0" {
+				text = page.Translation().Text_DependencyGraphOption("unlimited")
+			}
+			if d != options.graphDepth {
+				text = fmt.Sprintf(`<a href="?graphdepth=%s&graphstd=%s#dependency-graph">%s</a>`, d, options.graphStd, text)
+			}
+			depthLinks = append(depthLinks, text)
+		}
+
+		var stdLinks = make([]string, 0, 2)
+		for _, s := range []string{"show", "hide"} {
+			text := page.Translation().Text_DependencyGraphOption(s)
+			if s != options.graphStd {
+				text = fmt.Sprintf(`<a href="?graphdepth=%s&graphstd=%s#dependency-graph">%s</a>`, options.graphDepth, s, text)
+			}
+			stdLinks = append(stdLinks, text)
+		}
+
+		fmt.Fprintf(page, `<span class="title" id="dependency-graph">%s (%s%s%s%s%s)</span>`,
+			page.Translation().Text_DependencyGraph(),
+			page.Translation().Text_DependencyGraphOption("depth"),
+			strings.Join(depthLinks, " | "),
+			page.Translation().Text_Comma(),
+			page.Translation().Text_DependencyGraphOption("std"),
+			strings.Join(stdLinks, " | "),
+		)
+	}
+	fmt.Fprintf(page, `
+	<object type="image/svg+xml" data="%s"></object>`,
+		svgHref,
+	)
+}
+
+// Useful to find all the uses of a package, such as unsafe.
+func (ds *docServer) writeExportedObjectUses(page *htmlPage, depInfo *PackageDependencyInfo) {
+	for _, importedBy := range depInfo.ImportedBys {
+		objUses := depInfo.Uses[importedBy.Package]
+		if len(objUses) == 0 {
+			continue
+		}
+		numUses := 0
+		for _, ou := range objUses {
+			numUses += len(ou.Identifiers)
+		}
+
+		page.WriteString("\n\n\t")
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, importedBy.Path}, page, importedBy.Path)
+		fmt.Fprintf(page, "<i>%s</i>", page.Translation().Text_EnclosedInOarentheses(page.Translation().Text_ObjectUses(numUses)))
+
+		for _, ou := range objUses {
+			page.WriteString("\n\t\t")
+			// Fields of unnamed struct types not owned by package-level
+			// variables have no reference pages.
+			if buildIdUsesPages && (ou.Object.Parent() != nil || strings.IndexByte(ou.Name, '.') > 0) {
+				buildPageHref(page.PathInfo, pagePathInfo{ResTypeReference, depInfo.ImportPath + ".." + ou.Name}, page, ou.Name)
+			} else {
+				page.WriteString(ou.Name)
+			}
+			fmt.Fprintf(page, "<i>%s</i>: ", page.Translation().Text_EnclosedInOarentheses(page.Translation().Text_ObjectUses(len(ou.Identifiers))))
+
+			var fileInfo *code.SourceFileInfo
+			for i, id := range ou.Identifiers {
+				if i > 0 {
+					page.WriteString(", ")
+				}
+				pos := importedBy.Package.PPkg.Fset.PositionFor(id.AstIdent.NamePos, false)
+				if fileInfo == id.FileInfo {
+					writeSrouceCodeLineLink(page, importedBy.Package, pos, fmt.Sprintf("#L%d", pos.Line), "")
+				} else {
+					fileInfo = id.FileInfo
+					writeSrouceCodeLineLink(page, importedBy.Package, pos, fmt.Sprintf("%s#L%d", fileInfo.AstBareFileName(), pos.Line), "")
+				}
+			}
+		}
+	}
+}
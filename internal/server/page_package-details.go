@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/format"
+	"go/printer"
 	"go/token"
 	"go/types"
 	"io"
@@ -28,7 +30,7 @@ var _ = log.Print
 //}
 
 type packagePageOptions struct {
-	sortBy string // "alphabet", "popularity"
+	sortBy string // "alphabet", "popularity", "usage"
 	filter string // "all", "exported"
 }
 
@@ -39,6 +41,7 @@ func (ds *docServer) packageDetailsPage(w http.ResponseWriter, r *http.Request,
 
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -54,7 +57,7 @@ func (ds *docServer) packageDetailsPage(w http.ResponseWriter, r *http.Request,
 
 	var sortBy = r.FormValue("sortby")
 	switch sortBy {
-	case "alphabet", "popularity":
+	case "alphabet", "popularity", "usage":
 	default:
 		if ok {
 			sortBy = oldOptions.sortBy
@@ -134,6 +137,16 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		page.Translation().Text_PackageDocsLinksOnOtherWebsites(pkg.ImportPath, pkg.IsStandard),
 	)
 
+	if mod := pkg.Package.Mod; mod != nil {
+		fmt.Fprintf(page, `
+
+<span class="title">%s</span>
+	`,
+			page.Translation().Text_BelongingModule(),
+		)
+		writeModuleLink(page, mod)
+	}
+
 	isBuiltin := pkg.ImportPath == "builtin"
 	if !isBuiltin {
 		fmt.Fprintf(page, `
@@ -146,6 +159,13 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		)
 	}
 
+	if violations := ds.analyzer.PackageImportViolations(pkg.Package); len(violations) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title" id="import-violations">`, page.Translation().Text_ImportViolations(), `</span>`)
+		for _, v := range violations {
+			writeImportViolation(page, v)
+		}
+	}
+
 	if len(pkg.Files) > 0 {
 		fmt.Fprint(page, "\n\n", `<span class="title">`, page.Translation().Text_InvolvedFiles(len(pkg.Files)), `</span>`)
 
@@ -183,6 +203,15 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 				}
 			}
 			writeSrouceCodeFileLink(page, pkg.Package, info.Filename)
+			writePlatforms(page, ds.analyzer.PlatformsOfSourceFile(pkg.Package, info.Filename))
+		}
+	}
+
+	if count := len(pkg.Package.Examples); count > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, page.Translation().Text_Examples(count), `</span>`)
+		for _, ex := range pkg.Package.Examples {
+			page.WriteString("\n\t")
+			ds.writeExampleFoldingBlock(page, "package", ex, "\t\t")
 		}
 	}
 
@@ -215,13 +244,7 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 			showExportedOnly = false
 		}
 
-		var textFilter string
-		switch options.sortBy {
-		case "alphabet":
-			textFilter = fmt.Sprintf(`<a href="%s%s">%s</a>`, "?sortby=alphabet", filterQuery2, filterLinkText)
-		case "popularity":
-			textFilter = fmt.Sprintf(`<a href="%s%s">%s</a>`, "?sortby=popularity", filterQuery2, filterLinkText)
-		}
+		var textFilter = fmt.Sprintf(`<a href="?sortby=%s%s">%s</a>`, options.sortBy, filterQuery2, filterLinkText)
 
 		page.WriteString("\n\n")
 		if len(pkg.ExportedTypeNames) <= 1 {
@@ -230,23 +253,28 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 				textFilter,
 			)
 		} else {
-			var textSortByPopularity, textSortByAlphabet string
-			switch options.sortBy {
-			case "alphabet":
-				textSortByPopularity = fmt.Sprintf(`<a href="%s%s">%s</a>`, "?sortby=popularity", filterQuery, page.Translation().Text_SortByItem("popularity"))
-				textSortByAlphabet = page.Translation().Text_SortByItem("alphabet")
-			case "popularity":
-				textSortByPopularity = page.Translation().Text_SortByItem("popularity")
-				textSortByAlphabet = fmt.Sprintf(`<a href="%s%s">%s</a>`, "?sortby=alphabet", filterQuery, page.Translation().Text_SortByItem("alphabet"))
+			var textSortByAlphabet = page.Translation().Text_SortByItem("alphabet")
+			var textSortByPopularity = page.Translation().Text_SortByItem("popularity")
+			var textSortByUsage = page.Translation().Text_SortByItem("usage")
+
+			if options.sortBy != "alphabet" {
+				textSortByAlphabet = fmt.Sprintf(`<a href="%s%s">%s</a>`, "?sortby=alphabet", filterQuery, textSortByAlphabet)
+			}
+			if options.sortBy != "popularity" {
+				textSortByPopularity = fmt.Sprintf(`<a href="%s%s">%s</a>`, "?sortby=popularity", filterQuery, textSortByPopularity)
+			}
+			if options.sortBy != "usage" {
+				textSortByUsage = fmt.Sprintf(`<a href="%s%s">%s</a>`, "?sortby=usage", filterQuery, textSortByUsage)
 			}
 
-			fmt.Fprintf(page, `<span class="title">%s (%s%s%s%s | %s)</span>`,
+			fmt.Fprintf(page, `<span class="title">%s (%s%s%s%s | %s | %s)</span>`,
 				textTypeNames,
 				textFilter,
 				page.Translation().Text_Comma(),
 				page.Translation().Text_SortBy(),
 				textSortByAlphabet,
 				textSortByPopularity,
+				textSortByUsage,
 			)
 		}
 		page.WriteByte('\n')
@@ -262,16 +290,34 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		page.WriteString("\n")
 		fmt.Fprintf(page, `<div class="anchor" id="name-%s" data-popularity="%d">`, et.TypeName.Name(), et.Popularity)
 		page.WriteByte('\t')
+		deprecated := ds.analyzer.ResourceDeprecation(et.TypeName) != ""
+		writeDeprecatedStart(page, deprecated)
 		ds.writeResourceIndexHTML(page, pkg.Package, et.TypeName, false)
+		writeDeprecatedEnd(page, deprecated)
+		writePlatforms(page, ds.analyzer.PlatformsOfResource(et.TypeName))
+		writeUseStat(page, et.UseStat)
 		if doc := et.TypeName.Documentation(); doc != "" {
 			page.WriteString("\n")
-			writePageText(page, "\t\t", doc, true)
+			writeResourceDocs(page, et.TypeName.Name(), doc, deprecated)
 		}
 
 		// ToDo: for alias, if its denoting type is an exported named type, then stop here.
 		//       (might be not a good idea. 1. such cases are rare. 2. if they happen, it does need to list ...)
 
 		page.WriteString("\n")
+		if itt, ok := et.TypeName.Denoting().TT.Underlying().(*types.Interface); ok {
+			if terms := typeSetTerms(itt); len(terms) > 0 {
+				page.WriteString("\n\t\t")
+				page.WriteString(page.Translation().Text_TypeSet())
+				page.WriteString(page.Translation().Text_Colon(true))
+				for i, term := range terms {
+					if i > 0 {
+						page.WriteString("; ")
+					}
+					ds.writeValueTType(page, term, pkg.Package, true, nil)
+				}
+			}
+		}
 		if count := len(et.Fields); count > 0 {
 			page.WriteString("\n\t\t")
 			writeFoldingBlock(page, et.TypeName.Name(), "fields",
@@ -281,6 +327,7 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 					fields := ds.sortFieldList(et.Fields)
 					for _, fld := range fields {
 						page.WriteString("\n\t\t\t")
+						deprecated := ds.analyzer.ObjectDeprecation(fld.Object()) != ""
 						if fldDoc, fldComment := fld.Field.Documentation(), fld.Field.Comment(); fldDoc == "" && fldComment == "" {
 							page.WriteString(`<span class="nodocs">`)
 							ds.writeFieldForListing(page, pkg.Package, fld, et.TypeName)
@@ -289,7 +336,9 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 							writeFoldingBlock(page, et.TypeName.Name(), "field-"+fld.Name(),
 								"",
 								func() {
+									writeDeprecatedStart(page, deprecated)
 									ds.writeFieldForListing(page, pkg.Package, fld, et.TypeName)
+									writeDeprecatedEnd(page, deprecated)
 								},
 								func() {
 									if fldDoc != "" {
@@ -317,26 +366,68 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 				nil,
 				func() {
 					methods := ds.sortMethodList(et.Methods)
+					_, isInterface := et.TypeName.Denoting().TT.Underlying().(*types.Interface)
 					for _, mthd := range methods {
 						page.WriteString("\n\t\t\t")
-						if mthdDoc, mthdComment := mthd.Method.Documentation(), mthd.Method.Comment(); mthdDoc == "" && mthdComment == "" {
-							page.WriteString(`<span class="nodocs">`)
+						var decls []code.InterfaceMethodDeclaration
+						if isInterface {
+							decls = ds.analyzer.InterfaceMethodDeclarations(et.TypeName, mthd)
+						}
+						deprecated := ds.analyzer.ObjectDeprecation(mthd.Object()) != ""
+						var writeMethod = func() {
+							writeDeprecatedStart(page, deprecated)
 							ds.writeMethodForListing(page, pkg.Package, mthd, et.TypeName, true, false)
+							writeDeprecatedEnd(page, deprecated)
+							ds.writeInheritedFrom(page, pkg.Package, decls)
+						}
+
+						// Embedded interface types might specify the same method with different docs.
+						type methodDoc struct {
+							doc, comment string
+							from         *code.TypeName // nil for direct ones
+						}
+						var docs []methodDoc
+						var listed = make(map[[2]string]bool, len(decls))
+						var addDoc = func(doc, comment string, from *code.TypeName) {
+							if key := [2]string{doc, comment}; key != [2]string{} && !listed[key] {
+								listed[key] = true
+								docs = append(docs, methodDoc{doc, comment, from})
+							}
+						}
+						if len(decls) == 0 {
+							addDoc(mthd.Method.Documentation(), mthd.Method.Comment(), nil)
+						}
+						for i := range decls {
+							addDoc(decls[i].Documentation(), decls[i].Comment(), decls[i].Interface)
+						}
+
+						if len(docs) == 0 {
+							page.WriteString(`<span class="nodocs">`)
+							writeMethod()
 							page.WriteString(`</span>`)
 						} else {
 							writeFoldingBlock(page, et.TypeName.Name(), "method-"+mthd.Name(),
 								"",
+								writeMethod,
 								func() {
-									ds.writeMethodForListing(page, pkg.Package, mthd, et.TypeName, true, false)
-								},
-								func() {
-									if mthdDoc != "" {
-										page.WriteString("\n")
-										writePageText(page, "\t\t\t\t", mthdDoc, true)
-									}
-									if mthdComment != "" {
-										page.WriteString("\n")
-										writePageText(page, "\t\t\t\t// ", mthdComment, true)
+									for _, md := range docs {
+										if len(decls) > 1 {
+											page.WriteString("\n\t\t\t\t<i>")
+											if md.from == nil {
+												page.WriteString(et.TypeName.Name())
+											} else {
+												ds.writeValueTType(page, md.from.Denoting().TT, pkg.Package, true, nil)
+											}
+											page.WriteString(":</i>")
+										}
+										if md.doc != "" {
+											page.WriteString("\n")
+											writePageText(page, "\t\t\t\t", md.doc, true)
+										}
+										if md.comment != "" {
+											page.WriteString("\n")
+											writePageText(page, "\t\t\t\t// ", md.comment, true)
+										}
 									}
 									page.WriteString("\n")
 								},
@@ -383,6 +474,10 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 				"items",
 				false)
 		}
+		if !isBuiltin && (len(et.ImplementedBys) > 0 || len(et.Implements) > 0 || hasEmbeddings(et.TypeName.Denoting())) {
+			page.WriteString("\n\t\t")
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypeHierarchy, pkg.ImportPath + "." + et.TypeName.Name()}, page, page.Translation().Text_TypeHierarchy())
+		}
 		if count := len(et.AsOutputsOf); count > 0 {
 			page.WriteString("\n\t\t")
 			writeFoldingBlock(page, et.TypeName.Name(), "results",
@@ -428,6 +523,20 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 				"items",
 				false)
 		}
+		if count := len(et.Examples); count > 0 {
+			page.WriteString("\n\t\t")
+			writeFoldingBlock(page, et.TypeName.Name(), "examples",
+				page.Translation().Text_Examples(count),
+				nil,
+				func() {
+					for _, ex := range et.Examples {
+						page.WriteString("\n\t\t\t")
+						ds.writeExampleFoldingBlock(page, et.TypeName.Name(), ex, "\t\t\t\t")
+					}
+				},
+				"items",
+				false)
+		}
 
 		page.WriteString("</div>")
 	}
@@ -450,19 +559,134 @@ WriteValues:
 		page.WriteByte('\n')
 		fmt.Fprintf(page, `<div class="anchor" id="name-%s">`, v.Name())
 		page.WriteByte('\t')
+		deprecated := ds.analyzer.ResourceDeprecation(v) != ""
+		writeDeprecatedStart(page, deprecated)
 		ds.writeResourceIndexHTML(page, pkg.Package, v, false)
+		writeDeprecatedEnd(page, deprecated)
+		writePlatforms(page, ds.analyzer.PlatformsOfResource(v))
+		writeUseStat(page, ds.analyzer.ResourceUseStat(v))
 		if doc := v.Documentation(); doc != "" {
 			page.WriteString("\n")
-			writePageText(page, "\t\t", doc, true)
+			writeResourceDocs(page, v.Name(), doc, deprecated)
+		}
+		if f, ok := v.(*code.Function); ok && len(f.Examples) > 0 {
+			page.WriteString("\n\n\t\t")
+			writeFoldingBlock(page, v.Name(), "examples",
+				page.Translation().Text_Examples(len(f.Examples)),
+				nil,
+				func() {
+					for _, ex := range f.Examples {
+						page.WriteString("\n\t\t\t")
+						ds.writeExampleFoldingBlock(page, v.Name(), ex, "\t\t\t\t")
+					}
+				},
+				"items",
+				false)
 		}
 		page.WriteString("</div>")
 	}
 
 Done:
+	ds.writeDeclarationsOnOtherPlatforms(page, pkg.Package, showExportedOnly)
+
 	page.WriteString("</code></pre>")
 	return page.Done(w)
 }
 
+// Deprecated declarations are struck through.
+func writeDeprecatedStart(page *htmlPage, deprecated bool) {
+	if deprecated {
+		page.WriteString(`<span class="deprecated">`)
+	}
+}
+
+func writeDeprecatedEnd(page *htmlPage, deprecated bool) {
+	if deprecated {
+		page.WriteString(`</span>`)
+	}
+}
+
+// The docs of deprecated declarations are collapsed.
+func writeResourceDocs(page *htmlPage, resName, doc string, deprecated bool) {
+	if !deprecated {
+		writePageText(page, "\t\t", doc, true)
+		return
+	}
+	page.WriteString("\t\t")
+	writeFoldingBlock(page, resName, "docs",
+		page.Translation().Text_Deprecated(),
+		nil,
+		func() {
+			page.WriteString("\n")
+			writePageText(page, "\t\t\t", doc, true)
+		},
+		"docs",
+		false)
+}
+
+// Declarations and files which don't exist on all the platforms
+// are annotated with the platforms where they exist.
+func writePlatforms(page *htmlPage, platforms []code.Platform) {
+	if len(platforms) == 0 {
+		return
+	}
+	list := make([]string, len(platforms))
+	for i, p := range platforms {
+		list[i] = p.String()
+	}
+	page.WriteString(" <i>")
+	page.WriteString(page.Translation().Text_OnlyOnPlatforms(strings.Join(list, ", ")))
+	page.WriteString("</i>")
+}
+
+// Uses in the declaring package are not counted.
+func writeUseStat(page *htmlPage, stat code.ObjectUseStat) {
+	if stat.Packages == 0 {
+		return
+	}
+	page.WriteString(" <i>")
+	page.WriteString(page.Translation().Text_UsedByPackages(stat.Packages, stat.Uses))
+	page.WriteString("</i>")
+}
+
+// The declarations and files which don't exist on the primary platform
+// are not analyzed, so the declarations are listed with their docs and
+// source code (without links).
+func (ds *docServer) writeDeclarationsOnOtherPlatforms(page *htmlPage, pkg *code.Package, exportedOnly bool) {
+	decls := ds.analyzer.DeclarationsOnOtherPlatforms(pkg)
+	if exportedOnly {
+		exporteds := decls[:0]
+		for _, decl := range decls {
+			if decl.Kind == "file" || token.IsExported(decl.Name) {
+				exporteds = append(exporteds, decl)
+			}
+		}
+		decls = exporteds
+	}
+	if len(decls) == 0 {
+		return
+	}
+
+	fmt.Fprint(page, "\n\n", `<span class="title">`, page.Translation().Text_DeclarationsOnOtherPlatforms(len(decls)), `</span>`)
+	page.WriteByte('\n')
+	for _, decl := range decls {
+		page.WriteByte('\n')
+		if decl.Source == "" {
+			page.WriteByte('\t')
+			page.WriteString(decl.Kind)
+			page.WriteByte(' ')
+			page.WriteString(decl.Name)
+		} else {
+			writePageText(page, "\t", decl.Source, true)
+		}
+		writePlatforms(page, decl.Platforms)
+		if decl.Doc != "" {
+			page.WriteString("\n")
+			writePageText(page, "\t\t", decl.Doc, true)
+		}
+	}
+}
+
 type FileInfo struct {
 	Filename     string
 	MainPosition *token.Position // for main packages only
@@ -531,7 +755,11 @@ type ExportedType struct {
 	AsInputsOf  []ValueForListing
 	AsOutputsOf []ValueForListing
 
+	// Including the examples of the methods of the type.
+	Examples []*code.Example
+
 	Popularity int
+	UseStat    code.ObjectUseStat // uses in other packages
 }
 
 // ToDo: adjust the coefficients
@@ -548,6 +776,14 @@ func (et *ExportedType) calculatePopularity() {
 		len(et.AsOutputsOf)*75
 }
 
+// Compare the numbers of the using packages firstly.
+func useStatLess(a, b code.ObjectUseStat) bool {
+	if a.Packages != b.Packages {
+		return a.Packages < b.Packages
+	}
+	return a.Uses < b.Uses
+}
+
 // ds should be locked before calling this method.
 //func (ds *docServer) buildPackageDetailsData(pkgPath string) *PackageDetails {
 func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, options packagePageOptions) *PackageDetails {
@@ -559,6 +795,8 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 	//analyzer.BuildCgoFileMappings(pkg)
 
 	alsoShowNonExporteds := options.filter == "all"
+	// Test-only declarations are not parts of the APIs of non-test packages.
+	hideTestOnlys := !alsoShowNonExporteds && !pkg.IsExternalTest()
 
 	isBuiltin := pkgPath == "builtin"
 
@@ -612,17 +850,17 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 			len(pkg.PackageAnalyzeResult.AllVariables)+
 			len(pkg.PackageAnalyzeResult.AllFunctions))
 	for _, c := range pkg.PackageAnalyzeResult.AllConstants {
-		if c.Exported() {
+		if c.Exported() && !(hideTestOnlys && c.TestOnly()) {
 			valueResources = append(valueResources, c)
 		}
 	}
 	for _, v := range pkg.PackageAnalyzeResult.AllVariables {
-		if v.Exported() {
+		if v.Exported() && !(hideTestOnlys && v.TestOnly()) {
 			valueResources = append(valueResources, v)
 		}
 	}
 	for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
-		if f.Exported() && !f.IsMethod() {
+		if f.Exported() && !f.IsMethod() && !(hideTestOnlys && f.TestOnly()) {
 			valueResources = append(valueResources, f)
 		}
 	}
@@ -649,7 +887,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 	var exportedTypesResources = make([]*ExportedType, 0, len(pkg.PackageAnalyzeResult.AllTypeNames))
 	//var unexportedTypesResources = make([]*code.TypeName, 0, len(pkg.PackageAnalyzeResult.AllTypeNames))
 	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
-		if alsoShowNonExporteds || tn.Exported() {
+		if (alsoShowNonExporteds || tn.Exported()) && !(hideTestOnlys && tn.TestOnly()) {
 			denoting := tn.Denoting()
 			et := &ExportedType{TypeName: tn}
 			exportedTypesResources = append(exportedTypesResources, et)
@@ -659,6 +897,8 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 				continue
 			}
 
+			et.Examples = buildTypeExampleList(pkg, tn)
+
 			et.Fields = buildTypeFieldList(denoting, alsoShowNonExporteds)
 			et.Methods = buildTypeMethodsList(denoting, alsoShowNonExporteds)
 			//et.ImplementedBys = make([]*code.TypeInfo, 0, len(denoting.ImplementedBys))
@@ -747,6 +987,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 	}
 	for _, et := range exportedTypesResources {
 		et.calculatePopularity()
+		et.UseStat = analyzer.ResourceUseStat(et.TypeName)
 	}
 
 	switch options.sortBy {
@@ -759,6 +1000,18 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 		sort.Slice(exportedTypesResources, func(i, j int) bool {
 			return exportedTypesResources[i].Popularity > exportedTypesResources[j].Popularity
 		})
+	case "usage":
+		// Values are also sorted by usage in this case.
+		sort.SliceStable(valueResources, func(i, j int) bool {
+			return useStatLess(analyzer.ResourceUseStat(valueResources[j]), analyzer.ResourceUseStat(valueResources[i]))
+		})
+		sort.Slice(exportedTypesResources, func(i, j int) bool {
+			a, b := exportedTypesResources[i], exportedTypesResources[j]
+			if a.UseStat != b.UseStat {
+				return useStatLess(b.UseStat, a.UseStat)
+			}
+			return strings.ToLower(a.TypeName.Name()) < strings.ToLower(b.TypeName.Name())
+		})
 	}
 	//sort.Slice(unexportedTypesResources, func(i, j int) bool {
 	//	// ToDo: cache lower names?
@@ -791,6 +1044,19 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 	}
 }
 
+func buildTypeExampleList(pkg *code.Package, tn *code.TypeName) []*code.Example {
+	examples := append([]*code.Example(nil), tn.Examples...)
+	for _, f := range pkg.AllFunctions {
+		if len(f.Examples) == 0 || !f.IsMethod() {
+			continue
+		}
+		if _, recvTypeName, _ := f.ReceiverTypeName(); recvTypeName == tn {
+			examples = append(examples, f.Examples...)
+		}
+	}
+	return examples
+}
+
 func buildTypeFieldList(denoting *code.TypeInfo, alsoShowNonExporteds bool) []*code.Selector {
 	fields := make([]*code.Selector, 0, len(denoting.AllFields))
 	for _, fld := range denoting.AllFields {
@@ -813,13 +1079,20 @@ func buildTypeMethodsList(denoting *code.TypeInfo, alsoShowNonExporteds bool) []
 
 func buildTypeImplementedByList(analyzer *code.CodeAnalyzer, denoting *code.TypeInfo, alsoShowNonExporteds bool, exceptTypeName *code.TypeName) []TypeForListing {
 	implementedBys := make([]TypeForListing, 0, len(denoting.ImplementedBys))
+	// Several instantiations of a generic type share the same type name.
+	listed := make(map[TypeForListing]bool, len(denoting.ImplementedBys))
 	for _, impledBy := range denoting.ImplementedBys {
 		bytn, isPointer := analyzer.RetrieveTypeName(impledBy)
 		if bytn != nil && bytn != exceptTypeName && (alsoShowNonExporteds || bytn.Exported()) {
-			implementedBys = append(implementedBys, TypeForListing{
+			key := TypeForListing{
 				TypeName:  bytn,
 				IsPointer: isPointer,
-			})
+			}
+			if listed[key] {
+				continue
+			}
+			listed[key] = true
+			implementedBys = append(implementedBys, key)
 		}
 	}
 	return implementedBys
@@ -1325,11 +1598,17 @@ func (ds *docServer) writeMethodForListing(page *htmlPage, docPkg *code.Package,
 	}
 
 	if writeReceiver {
+		var recv = "( T)"
 		if sel.PointerReceiverOnly() {
-			page.WriteString("(*T) ")
+			recv = "(*T)"
+		}
+		// Only the methods declared for the type have calls pages.
+		if buildIdUsesPages && forTypeName != nil && forTypeName.Alias == nil && sel.EmbeddingChain == nil && method.Pkg.Path() != "builtin" {
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypeCall, forTypeName.Package().Path() + ".." + forTypeName.Name() + "." + method.Name}, page, recv)
 		} else {
-			page.WriteString("( T) ")
+			page.WriteString(recv)
 		}
+		page.WriteByte(' ')
 	}
 
 	if method.Pkg.Path() == "builtin" {
@@ -1351,8 +1630,32 @@ func (ds *docServer) writeMethodForListing(page *htmlPage, docPkg *code.Package,
 	}
 }
 
+// Notes the embedded interface types through which an interface method is obtained.
+// Nothing is written if the method is specified in the interface type directly.
+func (ds *docServer) writeInheritedFrom(page *htmlPage, docPkg *code.Package, decls []code.InterfaceMethodDeclaration) {
+	if len(decls) == 0 {
+		return
+	}
+	for _, decl := range decls {
+		if decl.Interface == nil {
+			return
+		}
+	}
+	page.WriteString(" <i>(")
+	page.WriteString(page.Translation().Text_InheritedFrom())
+	for i, decl := range decls {
+		if i > 0 {
+			page.WriteString(", ")
+		}
+		ds.writeValueTType(page, decl.Interface.Denoting().TT, docPkg, true, nil)
+	}
+	page.WriteString(")</i>")
+}
+
 func (ds *docServer) writeMethodType(page *htmlPage, docPkg *code.Package, method *code.Method, forTypeName *code.TypeName) {
-	if method.AstFunc != nil {
+	if method.Instantiated || method.AstFunc == nil && method.AstField == nil {
+		ds.writeValueTType(page, method.Type.TT, docPkg, false, forTypeName)
+	} else if method.AstFunc != nil {
 		ds.WriteAstType(page, method.AstFunc.Type, method.Pkg, docPkg, false, nil, forTypeName)
 	} else {
 		ds.WriteAstType(page, method.AstField.Type, method.Pkg, docPkg, false, nil, forTypeName)
@@ -1463,6 +1766,10 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, currentPkg *code.Pac
 		writeResName()
 
 		if !writeResNameOnly {
+			if !isBuiltin {
+				ds.WriteAstTypeParamList(page, res.AstSpec.TypeParams, res.Pkg, res.Pkg, nil)
+			}
+
 			showSource := false
 			if isBuiltin {
 				// builtin package source code are fake.
@@ -1471,7 +1778,7 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, currentPkg *code.Pac
 				allowStar := res.Alias != nil
 				for t, done := res.AstSpec.Type, false; !done; {
 					switch e := t.(type) {
-					case *ast.Ident, *ast.SelectorExpr:
+					case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr, *ast.IndexListExpr:
 						showSource = true
 						done = true
 					case *ast.ParenExpr:
@@ -1570,7 +1877,12 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, currentPkg *code.Pac
 				recv = sig.Recv()
 			}
 
-			if buildIdUsesPages && !isBuiltin {
+			// The functions in the unsafe package are builtins, so they have no calls pages.
+			if buildIdUsesPages && !isBuiltin && res.Func != nil {
+				page.WriteByte(' ')
+				buildPageHref(page.PathInfo, pagePathInfo{ResTypeCall, res.Package().Path() + ".." + res.Name()}, page, "func")
+				page.WriteByte(' ')
+			} else if buildIdUsesPages && !isBuiltin {
 				page.WriteByte(' ')
 				buildPageHref(page.PathInfo, pagePathInfo{ResTypeReference, res.Package().Path() + ".." + res.Name()}, page, "func")
 				page.WriteByte(' ')
@@ -1613,7 +1925,8 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, currentPkg *code.Pac
 	//fmt.Fprint(page, ` <a href="#">{/}</a>`)
 }
 
-func (ds *docServer) writeTypeName(page *htmlPage, tt *types.Named, docPkg *code.Package, alternativeTypeName string) {
+// tt is a *types.Named or a *types.Alias.
+func (ds *docServer) writeTypeName(page *htmlPage, tt interface{ Obj() *types.TypeName }, docPkg *code.Package, alternativeTypeName string) {
 	objpkg := tt.Obj().Pkg()
 	isBuiltin := objpkg == nil
 	if isBuiltin {
@@ -1651,6 +1964,23 @@ func (ds *docServer) writeValueTType(page *htmlPage, tt types.Type, docPkg *code
 		} else {
 			ds.writeTypeName(page, tt, docPkg, "")
 		}
+		ds.writeTypeArgs(page, tt.TypeArgs(), docPkg, forTypeName)
+	case *types.Alias:
+		ds.writeTypeName(page, tt, docPkg, "")
+		ds.writeTypeArgs(page, tt.TypeArgs(), docPkg, forTypeName)
+	case *types.TypeParam:
+		page.WriteString(tt.Obj().Name())
+	case *types.Union:
+		for i := 0; i < tt.Len(); i++ {
+			if i > 0 {
+				page.Write(unionSeparator)
+			}
+			term := tt.Term(i)
+			if term.Tilde() {
+				page.Write(tilde)
+			}
+			ds.writeValueTType(page, term.Type(), docPkg, true, forTypeName)
+		}
 	case *types.Basic:
 		if forTypeName != nil && tt == forTypeName.Denoting().TT {
 			page.WriteString(tt.Name())
@@ -1720,6 +2050,34 @@ func (ds *docServer) writeValueTType(page *htmlPage, tt types.Type, docPkg *code
 	}
 }
 
+// The type set terms of a constraint interface type,
+// such as ~int | ~string in interface { ~int | ~string; M() }.
+func typeSetTerms(itt *types.Interface) []types.Type {
+	var terms []types.Type
+	for i := 0; i < itt.NumEmbeddeds(); i++ {
+		et := itt.EmbeddedType(i)
+		if _, ok := et.Underlying().(*types.Interface); ok {
+			continue
+		}
+		terms = append(terms, et)
+	}
+	return terms
+}
+
+func (ds *docServer) writeTypeArgs(page *htmlPage, typeArgs *types.TypeList, docPkg *code.Package, forTypeName *code.TypeName) {
+	if typeArgs.Len() == 0 {
+		return
+	}
+	page.Write(leftSquare)
+	for i := 0; i < typeArgs.Len(); i++ {
+		if i > 0 {
+			page.Write(comma)
+		}
+		ds.writeValueTType(page, typeArgs.At(i), docPkg, true, forTypeName)
+	}
+	page.Write(rightSquare)
+}
+
 func (ds *docServer) writeTuple(page *htmlPage, tuple *types.Tuple, docPkg *code.Package, variadic bool, forTypeName *code.TypeName) {
 	n := tuple.Len()
 	for i := 0; i < n; i++ {
@@ -1829,6 +2187,8 @@ var (
 	funcKeyword      = []byte("func")
 	structKeyword    = []byte("struct")
 	interfaceKeyword = []byte("interface")
+	unionSeparator   = []byte(" | ")
+	tilde            = []byte("~")
 
 	BoldTagStart = []byte("<b>")
 	BoldTagEnd   = []byte("</b>")
@@ -1847,6 +2207,14 @@ func (ds *docServer) WriteAstType(w *htmlPage, typeLit ast.Expr, codePkg, docPkg
 		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
 		w.Write(rightParen)
 	case *ast.Ident:
+		// Type parameters are not declared at package level.
+		if tn, ok := codePkg.PPkg.TypesInfo.Uses[node].(*types.TypeName); ok {
+			if _, ok := tn.Type().(*types.TypeParam); ok {
+				w.WriteString(node.Name)
+				return
+			}
+		}
+
 		// obj := codePkg.PPkg.TypesInfo.ObjectOf(node)
 		// The above one might return a *types.Var object for embedding field.
 		// So us the following one instead, to make sure it is a *types.TypeName.
@@ -1963,6 +2331,28 @@ func (ds *docServer) WriteAstType(w *htmlPage, typeLit ast.Expr, codePkg, docPkg
 	case *ast.StarExpr:
 		w.Write(star)
 		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
+	case *ast.IndexExpr: // instantiated type
+		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
+		w.Write(leftSquare)
+		ds.WriteAstType(w, node.Index, codePkg, docPkg, true, nil, forTypeName)
+		w.Write(rightSquare)
+	case *ast.IndexListExpr: // instantiated type
+		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
+		w.Write(leftSquare)
+		for i, index := range node.Indices {
+			if i > 0 {
+				w.Write(comma)
+			}
+			ds.WriteAstType(w, index, codePkg, docPkg, true, nil, forTypeName)
+		}
+		w.Write(rightSquare)
+	case *ast.BinaryExpr: // type set union in constraints: A | B
+		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
+		w.Write(unionSeparator)
+		ds.WriteAstType(w, node.Y, codePkg, docPkg, true, nil, forTypeName)
+	case *ast.UnaryExpr: // type set term in constraints: ~T
+		w.Write(tilde)
+		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
 	case *ast.Ellipsis: // possible? (yes, variadic parameters)
 		//panic("[...] should be impossible") // ToDo: go/types package has a case.
 		//w.Write(leftSquare)
@@ -2003,6 +2393,7 @@ func (ds *docServer) WriteAstType(w *htmlPage, typeLit ast.Expr, codePkg, docPkg
 			w.Write(funcKeyword)
 			//w.Write(space)
 		}
+		ds.WriteAstTypeParamList(w, node.TypeParams, codePkg, docPkg, forTypeName)
 		w.Write(leftParen)
 		ds.WriteAstFieldList(w, node.Params, true, comma, codePkg, docPkg, true, recvParam, forTypeName)
 		w.Write(rightParen)
@@ -2031,6 +2422,16 @@ func (ds *docServer) WriteAstType(w *htmlPage, typeLit ast.Expr, codePkg, docPkg
 	}
 }
 
+// Write the type parameter list of a generic type or function.
+func (ds *docServer) WriteAstTypeParamList(w *htmlPage, typeParams *ast.FieldList, codePkg, docPkg *code.Package, forTypeName *code.TypeName) {
+	if typeParams == nil || len(typeParams.List) == 0 {
+		return
+	}
+	w.Write(leftSquare)
+	ds.WriteAstFieldList(w, typeParams, true, comma, codePkg, docPkg, true, nil, forTypeName)
+	w.Write(rightSquare)
+}
+
 func (ds *docServer) WriteAstFieldList(w *htmlPage, fieldList *ast.FieldList, isParamOrResultList bool, sep []byte, codePkg, docPkg *code.Package, funcKeywordNeeded bool, recvParam *ast.Field, forTypeName *code.TypeName) {
 	if fieldList == nil {
 		return
@@ -2141,6 +2542,76 @@ func (ds *docServer) WriteAstFieldList(w *htmlPage, fieldList *ast.FieldList, is
 //	fmt.Fprintf(page, ` type <a href="#name-%[1]s">%[1]s</a>`, tn.Name())
 //}
 
+func (ds *docServer) writeExampleFoldingBlock(page *htmlPage, resName string, example *code.Example, indent string) {
+	writeFoldingBlock(page, resName, "example-"+example.Name,
+		"",
+		func() {
+			page.WriteString("Example")
+			page.WriteString(example.Name)
+		},
+		func() {
+			if example.Doc != "" {
+				page.WriteString("\n")
+				writePageText(page, indent, example.Doc, true)
+				page.WriteString("\n")
+			}
+			page.WriteString("\n")
+			writePageText(page, indent, exampleCode(example), true)
+			if example.Output != "" || example.EmptyOutput {
+				page.WriteString("\n\n")
+				if example.Unordered {
+					writePageText(page, indent, "// Unordered output:", true)
+				} else {
+					writePageText(page, indent, "// Output:", true)
+				}
+				page.WriteString("\n")
+				writePageText(page, indent+"// ", example.Output, true)
+			}
+			page.WriteString("\n")
+		},
+		"docs",
+		false)
+}
+
+// The output comment is excluded from the returned code,
+// and the braces of the function body are removed.
+func exampleCode(example *code.Example) string {
+	var comments = example.Comments
+	var body, isBlock = example.Code.(*ast.BlockStmt)
+	if isBlock && (example.Output != "" || example.EmptyOutput) {
+		var outputComment *ast.CommentGroup
+		for _, c := range comments {
+			if c.Pos() > body.Lbrace && c.End() < body.Rbrace {
+				outputComment = c
+			}
+		}
+		if outputComment != nil {
+			comments = make([]*ast.CommentGroup, 0, len(example.Comments))
+			for _, c := range example.Comments {
+				if c != outputComment {
+					comments = append(comments, c)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	node := &printer.CommentedNode{Node: example.Code, Comments: comments}
+	if err := format.Node(&buf, example.Fset, node); err != nil {
+		return err.Error()
+	}
+	code := buf.String()
+
+	if isBlock {
+		code = strings.TrimSpace(code)
+		code = strings.TrimPrefix(code, "{")
+		code = strings.TrimSuffix(code, "}")
+		code = strings.Replace(code, "\n\t", "\n", -1)
+		code = strings.Trim(code, "\n")
+	}
+	return code
+}
+
 // writeTitleContent and statTitle mutual exclusive, one and only one is non-zero.
 func writeFoldingBlock(page *htmlPage, resName, statName, statTitle string, writeTitleContent, listStatContent func(), contentKind string, expandInitially bool) {
 	checked := ""
@@ -13,6 +13,7 @@ func (ds *docServer) pngFile(w http.ResponseWriter, r *http.Request, pngFilename
 
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	pageKey := pageCacheKey{
 		resType: ResTypePNG,
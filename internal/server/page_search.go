@@ -0,0 +1,150 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+)
+
+// In generation mode, the search page is a static page which
+// searches the prebuilt search index with JavaScript.
+func (ds *docServer) searchPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	// The result pages are not cached.
+	w.Write(ds.buildSearchPage(w, r.FormValue("q")))
+}
+
+func (ds *docServer) buildSearchPage(w http.ResponseWriter, query string) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_Search(), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeNone, "search"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		page.Translation().Text_Search(),
+	)
+
+	ds.writeSearchForm(page, query)
+
+	page.WriteString(`<pre id="search-results">`)
+	if !genDocsMode && query != "" {
+		entries := ds.theSearchIndex().search(query)
+		if len(entries) == 0 {
+			page.WriteString("\t")
+			page.WriteString(page.Translation().Text_BlankList())
+			page.WriteString("\n")
+		}
+		for _, e := range entries {
+			fmt.Fprintf(page, "\t%s ", e.kind)
+			page.WriteString(`<a href="`)
+			page.WriteString(e.href(page.PathInfo))
+			page.WriteString(`">`)
+			page.WriteString(html.EscapeString(e.displayName()))
+			page.WriteString("</a>\n")
+			if synopsis := e.Synopsis(); synopsis != "" {
+				writePageText(page, "\t\t", synopsis, true)
+				page.WriteString("\n")
+			}
+		}
+	}
+	page.WriteString("</pre>")
+
+	if genDocsMode {
+		fmt.Fprintf(page, `
+<script src="%s"></script>
+<script>
+(function() {
+	var query = "";
+	var params = window.location.search.substring(1).split("&");
+	for (var i = 0; i < params.length; i++) {
+		var kv = params[i].split("=");
+		if (kv[0] == "q" && kv.length > 1) {
+			query = decodeURIComponent(kv[1].replace(/\+/g, " "));
+		}
+	}
+	document.getElementById("search-query").value = query;
+
+	var words = query.toLowerCase().split(/\s+/).filter(function(w) {return w != "";});
+	if (words.length == 0) {
+		return;
+	}
+
+	// Keep consistent with searchIndex.search.
+	var results = [];
+	for (var i = 0; i < searchIndex.length; i++) {
+		var e = searchIndex[i], score = 0, qualified = e[1].toLowerCase(), doc = null;
+		for (var j = 0; j < words.length; j++) {
+			var w = words[j];
+			if (e[2] == w) {
+				score += 100;
+			} else if (e[2].indexOf(w) == 0) {
+				score += 50;
+			} else if (qualified.indexOf(w) >= 0) {
+				score += 20;
+			} else if ((doc || (doc = e[5].toLowerCase())).indexOf(w) >= 0) {
+				score += 5;
+			} else {
+				score = -1;
+				break;
+			}
+		}
+		if (score >= 0) {
+			results.push({entry: e, score: score, qualified: qualified});
+		}
+	}
+	results.sort(function(a, b) {
+		if (a.score != b.score) {
+			return b.score - a.score;
+		}
+		if (a.qualified.length != b.qualified.length) {
+			return a.qualified.length - b.qualified.length;
+		}
+		return a.qualified < b.qualified ? -1 : a.qualified > b.qualified ? 1 : 0;
+	});
+	results = results.slice(0, %d);
+
+	var escape = function(s) {
+		return s.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;").replace(/"/g, "&quot;");
+	};
+	var html = "";
+	if (results.length == 0) {
+		html = "\t%s\n";
+	}
+	for (var i = 0; i < results.length; i++) {
+		var e = results[i].entry;
+		html += "\t" + e[0] + ' <a href="' + escape(e[3]) + '">' + escape(e[1]) + "</a>\n";
+		if (e[4] != "") {
+			html += "\t\t" + escape(e[4]) + "\n";
+		}
+	}
+	document.getElementById("search-results").innerHTML = html;
+})();
+</script>
+`,
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypeJS, "search-index"}, nil, ""),
+			maxNumSearchResults,
+			page.Translation().Text_BlankList(),
+		)
+	}
+
+	return page.Done(w)
+}
+
+func (ds *docServer) writeSearchForm(page *htmlPage, query string) {
+	fmt.Fprintf(page, `
+<pre><form action="%s"><input type="text" id="search-query" name="q" value="%s" size="50"> <input type="submit" value="%s"></form></pre>
+`,
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "search"}, nil, ""),
+		html.EscapeString(query),
+		page.Translation().Text_Search(),
+	)
+}
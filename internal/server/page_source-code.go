@@ -6,12 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/doc"
 	"go/token"
 	"go/types"
+	"html"
 	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"go101.org/golds/code"
 )
@@ -28,6 +32,7 @@ func (ds *docServer) sourceCodePage(w http.ResponseWriter, r *http.Request, pkgP
 
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -170,6 +175,38 @@ func (ds *docServer) buildSourceCodePage(w http.ResponseWriter, result *SourceFi
 	page.WriteString(`
 </pre>`)
 
+	if len(result.InterfaceMethodCalls) > 0 {
+		pkg := ds.analyzer.PackageByPath(result.PkgPath)
+		fmt.Fprintf(page, `
+<pre id="interface-method-calls"><code><span class="title">%s</span>`,
+			page.Translation().Text_InterfaceMethodCallImplementations(),
+		)
+		for i, call := range result.InterfaceMethodCalls {
+			dotMStyle := DotMStyle_Unexported
+			if token.IsExported(call.Method.Name()) {
+				dotMStyle = DotMStyle_Exported
+			}
+			fmt.Fprintf(page, `
+<div class="anchor" id="impls-%d">	<a href="#line-%d">%s</a>: `,
+				i, call.Line, page.Translation().Text_SourceLine(call.Line),
+			)
+			ds.writeTypeForListing(page, &TypeForListing{TypeName: call.Receiver}, pkg, "", dotMStyle)
+			page.WriteByte('.')
+			ds.writeMethodForListing(page, pkg, call.Method, nil, false, true)
+			for _, imp := range call.Implementations {
+				page.WriteString("\n\t\t")
+				ds.writeTypeForListing(page, imp.Receiver, pkg, "", dotMStyle)
+				page.WriteByte('.')
+				ds.WriteEmbeddingChain(page, imp.Method.EmbeddingChain)
+				page.WriteString("<b>")
+				ds.writeMethodForListing(page, pkg, imp.Method, nil, false, true)
+				page.WriteString("</b>")
+			}
+			page.WriteString("</div>")
+		}
+		page.WriteString("</code></pre>")
+	}
+
 	return page.Done(w)
 }
 
@@ -182,6 +219,18 @@ type SourceFileAnalyzeResult struct {
 	NumRatios     int32
 	DocStartLine  int
 	DocEndLine    int
+
+	InterfaceMethodCalls []InterfaceMethodCall
+}
+
+// An interface method call site and the concrete methods which might be
+// called at it. Only the methods of the types whose values are statically
+// assigned to the values of the receiver interface type are listed.
+type InterfaceMethodCall struct {
+	Line            int
+	Receiver        *code.TypeName // the static receiver interface type
+	Method          *code.Selector
+	Implementations []MethodInfo
 }
 
 var (
@@ -497,6 +546,19 @@ type astVisitor struct {
 
 	topLevelStructTypeNodeDepth int32
 	topLevelStructTypeSpec      *ast.TypeSpec
+
+	// For package-level variables of unnamed struct types.
+	topLevelStructVarNodeDepth int32
+	topLevelStructVarSpec      *ast.ValueSpec
+
+	// Returns nil if the implementation data is unavailable.
+	methodImplementations func(itype *code.TypeInfo) *MethodImplementationResult
+
+	// The Sel parts of the shortened selectors (ones through embedded fields).
+	promotedSelectors map[*ast.Ident]*types.Selection
+
+	// The tooltip of the identifier being handled.
+	pendingTitle string
 }
 
 type astFunctionInfo struct {
@@ -721,7 +783,7 @@ func (v *astVisitor) buildText(litStart, litEnd token.Position, class, link stri
 		v.buildConfirmedLines(litEnd.Line, class)
 	}
 	if link != "" {
-		fmt.Fprintf(&v.lineBuilder, `<a href="%s">`, link)
+		fmt.Fprintf(&v.lineBuilder, `<a href="%s"%s>`, link, v.titleAttr())
 		defer fmt.Fprintf(&v.lineBuilder, `</a>`)
 	}
 	// This segment will not cross lines for sure.
@@ -732,7 +794,7 @@ func (v *astVisitor) buildText(litStart, litEnd token.Position, class, link stri
 func (v *astVisitor) buildLink(idStart, idEnd token.Position, link string) {
 	v.buildConfirmedLines(idStart.Line, "")
 	v.writeEscapedHTML(v.content[v.offset:idStart.Offset], "")
-	fmt.Fprintf(&v.lineBuilder, `<a href="%s" class="%s">`, link, "ident")
+	fmt.Fprintf(&v.lineBuilder, `<a href="%s" class="%s"%s>`, link, "ident", v.titleAttr())
 	defer v.lineBuilder.WriteString(`</a>`)
 	v.writeEscapedHTML(v.content[idStart.Offset:idEnd.Offset], "")
 	v.offset = idEnd.Offset
@@ -757,7 +819,7 @@ func (v *astVisitor) buildIdentifier(idStart, idEnd token.Position, ratioId int3
 	v.writeEscapedHTML(v.content[v.offset:idStart.Offset], "")
 
 	if ratioId >= 0 {
-		fmt.Fprintf(&v.lineBuilder, `<label for="r%d" class="%s">`, ratioId, class)
+		fmt.Fprintf(&v.lineBuilder, `<label for="r%d" class="%s"%s>`, ratioId, class, v.titleAttr())
 		defer v.lineBuilder.WriteString(`</label>`)
 	}
 
@@ -765,13 +827,18 @@ func (v *astVisitor) buildIdentifier(idStart, idEnd token.Position, ratioId int3
 		if ratioId >= 0 {
 		}
 		//if id == "" {
-		fmt.Fprintf(&v.lineBuilder, `<a href="%s" class="%s">`, link, class)
+		fmt.Fprintf(&v.lineBuilder, `<a href="%s" class="%s"%s>`, link, class, v.titleAttr())
 		//} else {
 		//	v.lineBuilder.WriteString(`<a href="` + link + `" class="` + class + `" id="` + id + `">`)
 		//}
 		defer v.lineBuilder.WriteString(`</a>`)
 	}
 
+	if attr := v.titleAttr(); attr != "" {
+		fmt.Fprintf(&v.lineBuilder, `<span%s>`, attr)
+		defer v.lineBuilder.WriteString(`</span>`)
+	}
+
 	//v.lineBuilder.Write(v.content[startOffset:endOffset])
 	v.writeEscapedHTML(v.content[idStart.Offset:idEnd.Offset], "")
 
@@ -780,6 +847,87 @@ func (v *astVisitor) buildIdentifier(idStart, idEnd token.Position, ratioId int3
 	v.offset = idEnd.Offset
 }
 
+// Returns the title attribute for the pending tooltip, which is then cleared.
+func (v *astVisitor) titleAttr() string {
+	if v.pendingTitle == "" {
+		return ""
+	}
+	attr := ` title="` + html.EscapeString(v.pendingTitle) + `"`
+	v.pendingTitle = ""
+	return attr
+}
+
+// Identifiers without links are wrapped in spans to carry their tooltips.
+func (v *astVisitor) buildPendingTitle(idStart, idEnd token.Position) {
+	if v.pendingTitle == "" {
+		return
+	}
+	if v.offset > idStart.Offset { // should not happen
+		v.pendingTitle = ""
+		return
+	}
+	v.buildIdentifier(idStart, idEnd, -1, "")
+}
+
+// The max length of the object part in a tooltip.
+const maxTooltipObjectLength = 256
+
+// The tooltip of an identifier: the object (with its type), the full selector
+// path if the identifier is a shortened selector, and the synopsis of the
+// object documentation. They are shown in separated lines.
+func (v *astVisitor) identifierTooltip(ident *ast.Ident, obj types.Object) string {
+	var b strings.Builder
+	objStr := types.ObjectString(obj, types.RelativeTo(v.pkg.PPkg.Types))
+	if len(objStr) > maxTooltipObjectLength {
+		n := maxTooltipObjectLength
+		for n > 0 && !utf8.RuneStart(objStr[n]) {
+			n--
+		}
+		objStr = objStr[:n] + "..."
+	}
+	b.WriteString(objStr)
+
+	if sel := v.promotedSelectors[ident]; sel != nil {
+		if path := v.promotedSelectorPath(sel); path != "" {
+			b.WriteByte('\n')
+			b.WriteString(path)
+		}
+	}
+
+	if synopsis := doc.Synopsis(v.dataAnalyzer.ObjectDocumentation(obj)); synopsis != "" {
+		b.WriteByte('\n')
+		b.WriteString(synopsis)
+	}
+	return b.String()
+}
+
+// Such as "[field] Base.*Inner.X".
+func (v *astVisitor) promotedSelectorPath(sel *types.Selection) string {
+	recv := sel.Recv()
+	if ptr, ok := recv.Underlying().(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	t := v.dataAnalyzer.TryRegisteringType(recv, false)
+	if t == nil {
+		return ""
+	}
+	selectors := t.AllMethods
+	if sel.Kind() == types.FieldVal {
+		selectors = t.AllFields
+	}
+	obj := sel.Obj()
+	for _, s := range selectors {
+		if s.Name() != obj.Name() {
+			continue
+		}
+		if !obj.Exported() && s.Package().Path() != obj.Pkg().Path() {
+			continue
+		}
+		return s.String()
+	}
+	return ""
+}
+
 func (v *astVisitor) finish() {
 	v.tryToHandleSomeSpecialNodes(nil)
 
@@ -906,6 +1054,15 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 		if v.topLevelStructTypeSpec != nil && n.Pos() > v.topLevelStructTypeSpec.End() {
 			v.topLevelStructTypeSpec = nil
 		}
+		if v.topLevelStructVarSpec != nil && n.Pos() > v.topLevelStructVarSpec.End() {
+			v.topLevelStructVarSpec = nil
+		}
+	}
+
+	if se, ok := n.(*ast.SelectorExpr); ok {
+		if sel, ok := v.info.Selections[se]; ok && len(sel.Index()) > 1 {
+			v.promotedSelectors[se.Sel] = sel
+		}
 	}
 
 	if v.topLevelFuncInfo == nil {
@@ -925,6 +1082,10 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 							typeExpr = e.X
 						case *ast.StarExpr:
 							typeExpr = e.X
+						case *ast.IndexExpr: // generic receiver base types
+							typeExpr = e.X
+						case *ast.IndexListExpr: // generic receiver base types
+							typeExpr = e.X
 						default:
 							panic(fmt.Sprintf("impossible type: %T", e))
 						}
@@ -967,6 +1128,14 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 		}
 	}
 
+	if vs, ok := n.(*ast.ValueSpec); ok && v.topLevelFuncInfo == nil && v.topLevelStructVarSpec == nil {
+		_, ok := vs.Type.(*ast.StructType)
+		if ok && len(vs.Names) == 1 && vs.Names[0].Name != "_" {
+			v.topLevelStructVarSpec = vs
+			v.topLevelStructVarNodeDepth = v.astNodeDepth
+		}
+	}
+
 	// ...
 	v.astNodeDepth++
 
@@ -1234,6 +1403,9 @@ func (v *astVisitor) handleIdent(ident *ast.Ident) {
 		return
 	}
 
+	v.pendingTitle = v.identifierTooltip(ident, obj)
+	defer v.buildPendingTitle(start, end)
+
 	//log.Printf("=== %s: %T\n", ident.Name, obj)
 
 	if pkgName, ok := obj.(*types.PkgName); ok {
@@ -1443,7 +1615,7 @@ GoOn:
 					}
 				}
 			case *types.Var: // struct field
-				// ToDo: generate fake IDs for unnamed types.
+				// ToDo: generate fake IDs for other unnamed types.
 				// 5 depth distane from struct type spec to field ident.
 				if v.topLevelStructTypeSpec != nil && v.astNodeDepth-v.topLevelStructTypeNodeDepth == 5 {
 					enclosingTypeName := v.topLevelStructTypeSpec.Name.Name
@@ -1453,6 +1625,15 @@ GoOn:
 						return
 					}
 				}
+				// The variable names are used as fake type names.
+				if v.topLevelStructVarSpec != nil && v.astNodeDepth-v.topLevelStructVarNodeDepth == 5 {
+					varName := v.topLevelStructVarSpec.Names[0].Name
+					fieldName := obj.Name()
+					if fieldName != "_" && buildIdUsesPages {
+						v.buildLink(start, end, buildPageHref(v.currentPathInfo, pagePathInfo{ResTypeReference, objPkgPath + ".." + varName + "." + obj.Name()}, nil, ""))
+						return
+					}
+				}
 			}
 
 		case scp.Parent() == types.Universe: // package-level elements
@@ -1490,6 +1671,17 @@ GoOn:
 
 	v.buildIdentifier(start, end, -1, buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, objPkg, objPos))
 
+	// Interface method call sites are followed by markers linking
+	// to the implementation lists at the page bottom.
+	if f, ok := obj.(*types.Func); ok && enableSoruceNavigation {
+		if call := v.interfaceMethodCall(ident, f); call != nil {
+			call.Line = start.Line
+			fmt.Fprintf(&v.lineBuilder, `<sup><a href="#impls-%d" class="impls">*</a></sup>`, len(v.result.InterfaceMethodCalls))
+			v.result.InterfaceMethodCalls = append(v.result.InterfaceMethodCalls, *call)
+			return
+		}
+	}
+
 	// Handle interface embedding interface cases.
 	if v.topLevelInterfaceTypeInfo != nil && len(v.topLevelInterfaceTypeInfo.Methods) > 0 {
 		if ident.Pos() == v.topLevelInterfaceTypeInfo.Methods[0].Pos() {
@@ -1500,6 +1692,50 @@ GoOn:
 	return
 }
 
+// Nil is returned if ident is not the method name at an interface method
+// call site, or no concrete methods might be called at the call site.
+func (v *astVisitor) interfaceMethodCall(ident *ast.Ident, method *types.Func) *InterfaceMethodCall {
+	itype := v.dataAnalyzer.InterfaceMethodCallType(ident)
+	// ToDo: also support unnamed interface types.
+	if itype == nil || itype.TypeName == nil || v.methodImplementations == nil {
+		return nil
+	}
+	result := v.methodImplementations(itype)
+	if result == nil {
+		return nil
+	}
+	for _, m := range result.Methods {
+		if m.Method.Name() != method.Name() {
+			continue
+		}
+		if !token.IsExported(method.Name()) && m.Method.Package().Path() != method.Pkg().Path() {
+			continue
+		}
+		var impls []MethodInfo
+		for _, imp := range m.Implementations {
+			if imp.Interface {
+				continue
+			}
+			t := imp.Receiver.TypeName.Denoting()
+			if imp.Receiver.IsPointer {
+				t = v.dataAnalyzer.TryRegisteringType(types.NewPointer(t.TT), false)
+			}
+			if t != nil && v.dataAnalyzer.InterfaceValueMightHaveType(itype, t) {
+				impls = append(impls, imp)
+			}
+		}
+		if len(impls) == 0 {
+			return nil
+		}
+		return &InterfaceMethodCall{
+			Receiver:        itype.TypeName,
+			Method:          m.Method,
+			Implementations: impls,
+		}
+	}
+	return nil
+}
+
 func buildSrouceCodeLineLink(currentPathInfo pagePathInfo, analyzer *code.CodeAnalyzer, pkg *code.Package, p token.Position) string {
 	//return "/src:" + analyzer.OriginalGoSourceFile(p.Filename) + "#line-" + strconv.Itoa(p.Line)
 	//return buildPageHref(ResTypeSource, analyzer.OriginalGoSourceFile(p.Filename), false, "", nil) + "#line-" + strconv.Itoa(p.Line)
@@ -1745,6 +1981,17 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 			//pendingTokenPoses: make([]TokenPos, 0, 10),
 
 			sameFileObjects: make(map[types.Object]int32, 256),
+
+			promotedSelectors: make(map[*ast.Ident]*types.Selection, 64),
+		}
+		var implsCache = make(map[*code.TypeInfo]*MethodImplementationResult)
+		av.methodImplementations = func(itype *code.TypeInfo) *MethodImplementationResult {
+			result, cached := implsCache[itype]
+			if !cached {
+				result, _ = ds.buildImplementationData(ds.analyzer, itype.TypeName.Package().Path(), itype.TypeName.Name())
+				implsCache[itype] = result
+			}
+			return result
 		}
 		av.lineBuilder.Grow(1024)
 
@@ -12,6 +12,7 @@ func (ds *docServer) statisticsPage(w http.ResponseWriter, r *http.Request) {
 
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -119,6 +120,8 @@ func (ds *docServer) buildStatisticsPage(w http.ResponseWriter) []byte {
 		"averageIdentiferLength": float64(stats.ExportedIdentifersSumLength) / float64(stats.ExportedIdentifers),
 
 		"exportedidentifiersByLengthsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedidentifiers-by-lengths"}, nil, ""),
+
+		"unusedExportedIdentifiersURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "unused"}, nil, ""),
 	}))
 
 	return page.Done(w)
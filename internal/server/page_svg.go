@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+
+	"go101.org/golds/code"
 )
 
 func (ds *docServer) svgFile(w http.ResponseWriter, r *http.Request, svgFile string) {
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
 
 	if ds.phase < Phase_Analyzed {
 		w.Header().Set("Content-Type", "text/html")
@@ -75,49 +80,58 @@ func (ds *docServer) buildSVG(svgFile string, page *htmlPage) (svgData []byte) {
 		}
 	}
 
+	bgColor, fgColor := ds.currentTheme.ChartColors()
+	if strings.HasPrefix(svgFile, "deps/") {
+		if depth, showStd, pkgPath, ok := parseDependencyGraphSVGPath(svgFile); ok {
+			if pkg := ds.analyzer.PackageByPath(pkgPath); pkg != nil {
+				svgData = ds.createDependencyGraphSVG(page, pkg, depth, showStd, bgColor, fgColor)
+			}
+		}
+		return
+	}
+
 	stats := ds.analyzer.Statistics()
 	chartTitle := page.Translation().Text_ChartTitle(svgFile)
 	switch svgFile {
 	case "gosourcefiles-by-imports":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.FilesByImportCount[:], xName(len(stats.FilesByImportCount)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.FilesByImportCount[:], xName(len(stats.FilesByImportCount)-1), bgColor, fgColor)
 	case "packages-by-dependencies":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.PackagesByDeps[:], xName(len(stats.PackagesByDeps)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.PackagesByDeps[:], xName(len(stats.PackagesByDeps)-1), bgColor, fgColor)
 	case "exportedtypenames-by-kinds":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedTypeNamesByKind[1:], kindName)
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedTypeNamesByKind[1:], kindName, bgColor, fgColor)
 	case "exportedstructtypes-by-embeddingfields":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByEmbeddingFieldCount[:], xName(len(stats.ExportedNamedStructsByEmbeddingFieldCount)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByEmbeddingFieldCount[:], xName(len(stats.ExportedNamedStructsByEmbeddingFieldCount)-1), bgColor, fgColor)
 	//case "exportedstructtypes-by-allfields":
-	//	svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByFieldCount[:], xName(len(stats.ExportedNamedStructsByFieldCount)-1))
+	//	svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByFieldCount[:], xName(len(stats.ExportedNamedStructsByFieldCount)-1), bgColor, fgColor)
 	case "exportedstructtypes-by-explicitfields":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByExplicitFieldCount[:], xName(len(stats.ExportedNamedStructsByExplicitFieldCount)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByExplicitFieldCount[:], xName(len(stats.ExportedNamedStructsByExplicitFieldCount)-1), bgColor, fgColor)
 	//case "exportedstructtypes-by-exportedfields":
-	//	svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByExportedFieldCount[:], xName(len(stats.ExportedNamedStructsByExportedFieldCount)-1))
+	//	svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByExportedFieldCount[:], xName(len(stats.ExportedNamedStructsByExportedFieldCount)-1), bgColor, fgColor)
 	case "exportedstructtypes-by-exportedexplicitfields":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByExportedExplicitFieldCount[:], xName(len(stats.ExportedNamedStructsByExportedExplicitFieldCount)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByExportedExplicitFieldCount[:], xName(len(stats.ExportedNamedStructsByExportedExplicitFieldCount)-1), bgColor, fgColor)
 	case "exportedstructtypes-by-exportedpromotedfields":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByExportedPromotedFieldCount[:], xName(len(stats.ExportedNamedStructsByExportedPromotedFieldCount)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedStructsByExportedPromotedFieldCount[:], xName(len(stats.ExportedNamedStructsByExportedPromotedFieldCount)-1), bgColor, fgColor)
 	case "exportedfunctions-by-parameters":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedFunctionsByParameterCount[:], xName(len(stats.ExportedFunctionsByParameterCount)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedFunctionsByParameterCount[:], xName(len(stats.ExportedFunctionsByParameterCount)-1), bgColor, fgColor)
 	case "exportedfunctions-by-results":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedFunctionsByResultCount[:], xName(len(stats.ExportedFunctionsByResultCount)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedFunctionsByResultCount[:], xName(len(stats.ExportedFunctionsByResultCount)-1), bgColor, fgColor)
 	case "exportedidentifiers-by-lengths":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedIdentifiersByLength[1:], xNameFromOne(len(stats.ExportedIdentifiersByLength)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedIdentifiersByLength[1:], xNameFromOne(len(stats.ExportedIdentifiersByLength)-1), bgColor, fgColor)
 	case "exportednoninterfacetypes-by-exportedmethods":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedNonInterfaceTypesByExportedMethodCount[:], xName(len(stats.ExportedNamedNonInterfaceTypesByExportedMethodCount)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedNonInterfaceTypesByExportedMethodCount[:], xName(len(stats.ExportedNamedNonInterfaceTypesByExportedMethodCount)-1), bgColor, fgColor)
 	case "exportedvariables-by-typekinds":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedVariablesByTypeKind[1:], kindName)
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedVariablesByTypeKind[1:], kindName, bgColor, fgColor)
 	case "exportedconstants-by-typekinds":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedConstantsByTypeKind[1:], kindName)
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedConstantsByTypeKind[1:], kindName, bgColor, fgColor)
 	case "exportedinterfacetypes-by-exportedmethods":
-		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedInterfacesByExportedMethodCount[:], xName(len(stats.ExportedNamedInterfacesByExportedMethodCount)-1))
+		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedInterfacesByExportedMethodCount[:], xName(len(stats.ExportedNamedInterfacesByExportedMethodCount)-1), bgColor, fgColor)
 	default:
 	}
 
 	return
 }
 
-// ToDo: add a bgColor parameter.
-func createSourcefileImportsSVG(title string, stat []int32, xName func(i int) string) []byte {
+func createSourcefileImportsSVG(title string, stat []int32, xName func(i int) string, bgColor, fgColor string) []byte {
 	if xName == nil {
 		xName = func(i int) string {
 			return strconv.Itoa(i)
@@ -169,17 +183,18 @@ func createSourcefileImportsSVG(title string, stat []int32, xName func(i int) st
 
 	buf := bytes.NewBuffer(make([]byte, 0, 1024*16))
 	fmt.Fprintf(buf, `<svg width="%d" height="%d" xmlns="http://www.w3.org/2000/svg">
-<rect fill="#fff" id="canvas_background" width="%d" height="%d" y="-1" x="-1"/>
+<rect fill="%s" id="canvas_background" width="%d" height="%d" y="-1" x="-1"/>
 `,
-		svgW, svgH, svgW+2, svgH+2,
+		svgW, svgH, bgColor, svgW+2, svgH+2,
 	)
 
 	barY := titleHeight + marginV
 
-	fmt.Fprintf(buf, `<text xml:space="preserve" text-anchor="middle" font-weight="bold" font-family='"Courier New", Courier, monospace' font-size="12" x="%d" y="%d" fill="#000">%s</text>
+	fmt.Fprintf(buf, `<text xml:space="preserve" text-anchor="middle" font-weight="bold" font-family='"Courier New", Courier, monospace' font-size="12" x="%d" y="%d" fill="%s">%s</text>
 `,
 		svgW/2,
 		barY-5,
+		fgColor,
 		title,
 	)
 
@@ -207,9 +222,9 @@ func createSourcefileImportsSVG(title string, stat []int32, xName func(i int) st
 			dotX := barX + dotRadius
 			dotY := barY + barH/2 - dotMargin
 			for range [3]struct{}{} {
-				fmt.Fprintf(buf, `<circle cx="%d" cy="%d" r="%d" fill="#000"/>
+				fmt.Fprintf(buf, `<circle cx="%d" cy="%d" r="%d" fill="%s"/>
 `,
-					dotX, dotY, dotRadius,
+					dotX, dotY, dotRadius, fgColor,
 				)
 				dotY += dotMargin
 			}
@@ -218,9 +233,9 @@ func createSourcefileImportsSVG(title string, stat []int32, xName func(i int) st
 			if maxV > 0 {
 				barWidth := float64(barMaxW) * float64(v) / float64(maxV)
 				valueTextX += barWidth
-				fmt.Fprintf(buf, `<rect x="%d" y="%d" width="%.2f" height="%d" fill="#000" />
+				fmt.Fprintf(buf, `<rect x="%d" y="%d" width="%.2f" height="%d" fill="%s" />
 `,
-					barX, barY, barWidth, barH,
+					barX, barY, barWidth, barH, fgColor,
 				)
 			}
 
@@ -228,18 +243,20 @@ func createSourcefileImportsSVG(title string, stat []int32, xName func(i int) st
 			nameTextX := barX - barMarginLeft
 			nameText := xName(i)
 
-			fmt.Fprintf(buf, `<text xml:space="preserve" text-anchor="end" font-family='"Courier New", Courier, monospace' font-size="12" x="%d" y="%d" fill="#000">%s</text>
+			fmt.Fprintf(buf, `<text xml:space="preserve" text-anchor="end" font-family='"Courier New", Courier, monospace' font-size="12" x="%d" y="%d" fill="%s">%s</text>
 `,
 				nameTextX,
 				textY,
+				fgColor,
 				nameText,
 			)
 
 			if v != 0 {
-				fmt.Fprintf(buf, `<text xml:space="preserve" text-anchor="start" font-style="italic" font-family='"Courier New", Courier, monospace' font-size="12" x="%.2f" y="%d" fill="#000">(%s)</text>
+				fmt.Fprintf(buf, `<text xml:space="preserve" text-anchor="start" font-style="italic" font-family='"Courier New", Courier, monospace' font-size="12" x="%.2f" y="%d" fill="%s">(%s)</text>
 `,
 					valueTextX,
 					textY,
+					fgColor,
 					strconv.Itoa(int(v)),
 				)
 			}
@@ -252,6 +269,151 @@ func createSourcefileImportsSVG(title string, stat []int32, xName func(i int) st
 	return buf.Bytes()
 }
 
+// The path of a dependency graph SVG looks like "deps/2/nonstd/example.com/foo".
+// The depth 0 means no limits.
+func dependencyGraphSVGPath(pkgPath string, depth int, showStd bool) string {
+	stdMode := "nonstd"
+	if showStd {
+		stdMode = "std"
+	}
+	return "deps/" + strconv.Itoa(depth) + "/" + stdMode + "/" + pkgPath
+}
+
+func parseDependencyGraphSVGPath(svgFile string) (depth int, showStd bool, pkgPath string, ok bool) {
+	tokens := strings.SplitN(svgFile, "/", 4)
+	if len(tokens) != 4 || tokens[0] != "deps" {
+		return
+	}
+	depth, err := strconv.Atoi(tokens[1])
+	if err != nil || depth < 0 {
+		return
+	}
+	switch tokens[2] {
+	default:
+		return
+	case "std":
+		showStd = true
+	case "nonstd":
+	}
+	return depth, showStd, tokens[3], true
+}
+
+// collectDependencyGraphNodes returns pkg and the packages it depends on
+// (directly or indirectly) within depth steps. The depth 0 means no limits.
+// Standard packages are excluded if showStd is false, unless pkg is standard.
+func (ds *docServer) collectDependencyGraphNodes(pkg *code.Package, depth int, showStd bool) map[*code.Package]bool {
+	showStd = showStd || ds.analyzer.IsStandardPackage(pkg)
+	nodes := map[*code.Package]bool{pkg: true}
+	layer := []*code.Package{pkg}
+	for step := 1; len(layer) > 0 && (depth == 0 || step <= depth); step++ {
+		var next []*code.Package
+		for _, p := range layer {
+			for _, dep := range p.Deps {
+				if nodes[dep] || !showStd && ds.analyzer.IsStandardPackage(dep) {
+					continue
+				}
+				nodes[dep] = true
+				next = append(next, dep)
+			}
+		}
+		layer = next
+	}
+	return nodes
+}
+
+// createDependencyGraphSVG draws the dependency graph of pkg. The packages
+// are layered by their dependency levels, so that all edges point downwards.
+// Each package node links to the corresponding package details page.
+func (ds *docServer) createDependencyGraphSVG(page *htmlPage, pkg *code.Package, depth int, showStd bool, bgColor, fgColor string) []byte {
+	nodes := ds.collectDependencyGraphNodes(pkg, depth, showStd)
+
+	layers := make(map[int][]*code.Package, 32)
+	levels := make([]int, 0, 32)
+	for p := range nodes {
+		if layers[p.DepLevel] == nil {
+			levels = append(levels, p.DepLevel)
+		}
+		layers[p.DepLevel] = append(layers[p.DepLevel], p)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(levels)))
+
+	const charW, paddingH, nodeH, nodeMarginH, layerMarginV, margin = 7.2, 6, 20, 10, 36, 8
+	type nodeBox struct{ x, y, w float64 }
+	boxes := make(map[*code.Package]nodeBox, len(nodes))
+	var svgW float64
+	for _, level := range levels {
+		var w float64
+		for _, p := range layers[level] {
+			w += float64(len(p.Path()))*charW + 2*paddingH + nodeMarginH
+		}
+		if w -= nodeMarginH; w > svgW {
+			svgW = w
+		}
+	}
+	svgW += 2 * margin
+	svgH := float64(len(levels))*(nodeH+layerMarginV) - layerMarginV + 2*margin
+
+	for i, level := range levels {
+		layer := layers[level]
+		sort.Slice(layer, func(a, b int) bool {
+			return layer[a].Path() < layer[b].Path()
+		})
+		var w float64
+		for _, p := range layer {
+			w += float64(len(p.Path()))*charW + 2*paddingH + nodeMarginH
+		}
+		x := (svgW - w + nodeMarginH) / 2
+		y := margin + float64(i)*(nodeH+layerMarginV)
+		for _, p := range layer {
+			box := nodeBox{x: x, y: y, w: float64(len(p.Path()))*charW + 2*paddingH}
+			boxes[p] = box
+			x += box.w + nodeMarginH
+		}
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024*16))
+	fmt.Fprintf(buf, `<svg width="%.0f" height="%.0f" xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">
+<rect fill="%s" id="canvas_background" width="%.0f" height="%.0f" y="-1" x="-1"/>
+`,
+		svgW, svgH, bgColor, svgW+2, svgH+2,
+	)
+
+	for _, level := range levels {
+		for _, p := range layers[level] {
+			from := boxes[p]
+			for _, dep := range p.Deps {
+				to, ok := boxes[dep]
+				if !ok {
+					continue
+				}
+				fmt.Fprintf(buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-opacity="0.35"/>
+`,
+					from.x+from.w/2, from.y+nodeH, to.x+to.w/2, to.y, fgColor,
+				)
+			}
+		}
+	}
+
+	for _, level := range levels {
+		for _, p := range layers[level] {
+			box := boxes[p]
+			fontWeight := "normal"
+			if p == pkg {
+				fontWeight = "bold"
+			}
+			fmt.Fprintf(buf, `<a xlink:href="%s" target="_top"><rect x="%.1f" y="%.1f" width="%.1f" height="%d" rx="3" fill="%s" stroke="%s"/><text xml:space="preserve" text-anchor="middle" font-weight="%s" font-family='"Courier New", Courier, monospace' font-size="12" x="%.1f" y="%.1f" fill="%s">%s</text></a>
+`,
+				buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, p.Path()}, nil, ""),
+				box.x, box.y, box.w, nodeH, bgColor, fgColor,
+				fontWeight, box.x+box.w/2, box.y+nodeH-6, fgColor, p.Path(),
+			)
+		}
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
 // ToDo: add a bgColor parameter.
 func createSourcefileImportsSVG_old(stat []int32, xName func(i int) string) []byte {
 	if xName == nil {
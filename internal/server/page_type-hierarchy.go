@@ -0,0 +1,326 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) typeHierarchyPage(w http.ResponseWriter, r *http.Request, pkgPath, typeName string) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeHierarchy,
+		res:     [...]string{pkgPath, typeName},
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		result, err := ds.buildTypeHierarchyData(pkgPath, typeName)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "Build type hierarchy for (", typeName, ") in ", pkgPath, " error: ", err)
+			return
+		}
+
+		data = ds.buildTypeHierarchyPage(w, result)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type TypeHierarchyResult struct {
+	TypeName    *code.TypeName
+	Package     *code.Package
+	IsInterface bool
+
+	// The embedded interfaces (for interface types) or
+	// the embedded fields (for struct types), as trees.
+	Embeddings []*TypeHierarchyNode
+
+	// For interface types, the implementers are listed.
+	// For other types, the implemented interfaces are listed.
+	Implementations    []TypeHierarchyGroup
+	NumImplementations int
+}
+
+type TypeHierarchyNode struct {
+	Field    *code.Field
+	Children []*TypeHierarchyNode
+}
+
+// Types grouped by packages.
+type TypeHierarchyGroup struct {
+	Package *code.Package
+	Types   []TypeForListing
+}
+
+func (ds *docServer) buildTypeHierarchyData(pkgPath, typeName string) (*TypeHierarchyResult, error) {
+	pkg := ds.analyzer.PackageByPath(pkgPath)
+	if pkg == nil {
+		return nil, errors.New("package not found")
+	}
+
+	var typeNameRes *code.TypeName
+	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
+		if tn.Name() == typeName {
+			typeNameRes = tn
+			break
+		}
+	}
+	if typeNameRes == nil {
+		return nil, errors.New("typename not found")
+	}
+
+	denoting := typeNameRes.Denoting()
+	result := &TypeHierarchyResult{
+		TypeName: typeNameRes,
+		Package:  pkg,
+	}
+
+	var implementations []TypeForListing
+	if _, ok := denoting.TT.Underlying().(*types.Interface); ok {
+		result.IsInterface = true
+		result.Embeddings = buildInterfaceEmbeddingTree(denoting, 0)
+		implementations = buildTypeImplementedByList(ds.analyzer, denoting, true, typeNameRes)
+	} else {
+		result.Embeddings = buildStructEmbeddingTree(denoting)
+		implementations = buildTypeImplementsList(ds.analyzer, denoting, true)
+	}
+
+	groups := make(map[*code.Package]int, len(implementations))
+	for _, t := range implementations {
+		i, ok := groups[t.Package()]
+		if !ok {
+			i = len(result.Implementations)
+			groups[t.Package()] = i
+			result.Implementations = append(result.Implementations, TypeHierarchyGroup{Package: t.Package()})
+		}
+		result.Implementations[i].Types = append(result.Implementations[i].Types, t)
+	}
+	sort.Slice(result.Implementations, func(a, b int) bool {
+		return result.Implementations[a].Package.Path() < result.Implementations[b].Package.Path()
+	})
+	for _, g := range result.Implementations {
+		sort.Slice(g.Types, func(a, b int) bool {
+			return g.Types[a].Name() < g.Types[b].Name()
+		})
+	}
+	result.NumImplementations = len(implementations)
+
+	return result, nil
+}
+
+// Whether or not a struct or interface type embeds other types.
+func hasEmbeddings(t *code.TypeInfo) bool {
+	if _, ok := t.TT.Underlying().(*types.Interface); ok {
+		for _, sel := range t.DirectSelectors {
+			if sel.Field != nil {
+				return true
+			}
+		}
+		return false
+	}
+	for _, sel := range t.AllFields {
+		if sel.Field.Mode != code.EmbedMode_None {
+			return true
+		}
+	}
+	return false
+}
+
+// The embedded types of an interface are recorded as fields in its direct selectors.
+func buildInterfaceEmbeddingTree(t *code.TypeInfo, depth int) []*TypeHierarchyNode {
+	if depth > 32 { // just for safety
+		return nil
+	}
+	var nodes []*TypeHierarchyNode
+	// Identical interface types share one TypeInfo, so their
+	// embedded types might be registered more than once.
+	embedded := make(map[*code.TypeInfo]struct{})
+	for _, sel := range t.DirectSelectors {
+		if sel.Field == nil {
+			continue
+		}
+		if _, ok := embedded[sel.Field.Type]; ok {
+			continue
+		}
+		embedded[sel.Field.Type] = struct{}{}
+		nodes = append(nodes, &TypeHierarchyNode{
+			Field:    sel.Field,
+			Children: buildInterfaceEmbeddingTree(sel.Field.Type, depth+1),
+		})
+	}
+	return nodes
+}
+
+// The promoted embedded fields are attached to the
+// embedded fields at the ends of their embedding chains.
+// Shadowed and colliding embedded fields are not listed.
+func buildStructEmbeddingTree(t *code.TypeInfo) []*TypeHierarchyNode {
+	type nodeKey struct {
+		field *code.Field
+		chain *code.EmbeddedField
+	}
+	var roots []*TypeHierarchyNode
+	var nodes = make(map[nodeKey]*TypeHierarchyNode, len(t.AllFields))
+	// Outer fields are always listed before inner ones.
+	for _, sel := range t.AllFields {
+		if sel.Field.Mode == code.EmbedMode_None {
+			continue
+		}
+		node := &TypeHierarchyNode{Field: sel.Field}
+		nodes[nodeKey{sel.Field, sel.EmbeddingChain}] = node
+		if sel.EmbeddingChain == nil {
+			roots = append(roots, node)
+		} else if parent := nodes[nodeKey{sel.EmbeddingChain.Field, sel.EmbeddingChain.Prev}]; parent != nil {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	return roots
+}
+
+func (ds *docServer) buildTypeHierarchyPage(w http.ResponseWriter, result *TypeHierarchyResult) []byte {
+	qualifiedTypeName := result.Package.Path() + "." + result.TypeName.Name()
+	title := ds.currentTranslation.Text_TypeHierarchy() + ds.currentTranslation.Text_Colon(true) + qualifiedTypeName
+	page := NewHtmlPage(goldsVersion, title, ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeHierarchy, qualifiedTypeName})
+
+	fmt.Fprintf(page, `<pre><code><span style="font-size:x-large;">type <a href="%s">%s</a>.`,
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, result.Package.Path()}, nil, ""),
+		result.Package.Path(),
+	)
+	page.WriteString("<b>")
+	ds.writeResourceIndexHTML(page, result.TypeName.Package(), result.TypeName, true)
+	page.WriteString(`</b></span><span style="font-size:large;">`)
+	writeKindText(page, result.TypeName.Denoting().TT)
+	page.WriteString("</span>\n")
+
+	if len(result.Embeddings) == 0 && len(result.Implementations) == 0 {
+		fmt.Fprintf(page, "\n<span class=\"title\">%s</span>\n\t%s\n", page.Translation().Text_TypeHierarchy(), page.Translation().Text_BlankList())
+	}
+
+	var foldID int
+	var nextFoldID = func() string {
+		foldID++
+		return strconv.Itoa(foldID)
+	}
+
+	if len(result.Embeddings) > 0 {
+		fmt.Fprintf(page, "\n<span class=\"title\">%s</span>", page.Translation().Text_EmbeddingTree(result.IsInterface))
+		var writeNodes func(nodes []*TypeHierarchyNode, indent string)
+		writeNodes = func(nodes []*TypeHierarchyNode, indent string) {
+			for _, node := range nodes {
+				page.WriteString("\n")
+				page.WriteString(indent)
+				if len(node.Children) == 0 {
+					ds.writeTypeHierarchyField(page, result.Package, node.Field)
+					continue
+				}
+				writeFoldingBlock(page, "embedding", nextFoldID(), "",
+					func() {
+						ds.writeTypeHierarchyField(page, result.Package, node.Field)
+					},
+					func() {
+						writeNodes(node.Children, indent+"\t")
+					},
+					"items",
+					true)
+			}
+		}
+		writeNodes(result.Embeddings, "\t")
+		page.WriteString("\n")
+	}
+
+	if len(result.Implementations) > 0 {
+		var implsTitle string
+		if result.IsInterface {
+			implsTitle = page.Translation().Text_ImplementedBy(result.NumImplementations)
+		} else {
+			implsTitle = page.Translation().Text_Implements(result.NumImplementations)
+		}
+		fmt.Fprintf(page, "\n<span class=\"title\">%s</span>", implsTitle)
+		for _, g := range result.Implementations {
+			page.WriteString("\n\t")
+			writeFoldingBlock(page, "implementations", nextFoldID(), "",
+				func() {
+					buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, g.Package.Path()}, page, g.Package.Path())
+					page.WriteString(page.Translation().Text_EnclosedInOarentheses(strconv.Itoa(len(g.Types))))
+				},
+				func() {
+					for i := range g.Types {
+						page.WriteString("\n\t\t")
+						if !result.IsInterface {
+							// Same as the package details page, IsPointer
+							// tells whether or not *T is the implementer.
+							t := g.Types[i]
+							if t.IsPointer {
+								page.WriteString("*T : ")
+							} else {
+								page.WriteString(" T : ")
+							}
+							t.IsPointer = false
+							ds.writeTypeHierarchyTypeName(page, result.Package, &t)
+							continue
+						}
+						ds.writeTypeHierarchyTypeName(page, result.Package, &g.Types[i])
+						if _, ok := g.Types[i].Denoting().TT.Underlying().(*types.Interface); ok {
+							page.WriteString(" <i>(interface)</i>")
+						}
+					}
+				},
+				"items",
+				true)
+		}
+		page.WriteString("\n")
+	}
+
+	// Method implementation pages are only built for the types with implementations.
+	if result.TypeName.Alias == nil && result.NumImplementations > 0 && enableSoruceNavigation {
+		fmt.Fprintf(page, "\n<span class=\"title\">%s</span>\n\t", page.Translation().Text_MethodImplementations())
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeImplementation, qualifiedTypeName}, page, qualifiedTypeName)
+		page.WriteString("\n")
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+func (ds *docServer) writeTypeHierarchyField(page *htmlPage, pkg *code.Package, field *code.Field) {
+	tn, isPointer := ds.analyzer.RetrieveTypeName(field.Type)
+	if tn == nil {
+		writeSrouceCodeLineLink(page, field.Pkg, field.Position(), field.Name, "")
+		return
+	}
+	ds.writeTypeHierarchyTypeName(page, pkg, &TypeForListing{TypeName: tn, IsPointer: isPointer})
+}
+
+// Unlike writeTypeForListing, the type names in the current
+// package are also linked to the package details page.
+func (ds *docServer) writeTypeHierarchyTypeName(page *htmlPage, pkg *code.Package, t *TypeForListing) {
+	if t.IsPointer {
+		page.WriteByte('*')
+	}
+	if t.Package() != pkg && t.Pkg.Path() != "builtin" {
+		page.WriteString(t.Pkg.Path())
+		page.WriteByte('.')
+	}
+	if t.Exported() {
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, t.Pkg.Path()}, page, t.Name(), "name-"+t.Name())
+	} else {
+		writeSrouceCodeLineLink(page, t.Pkg, t.Position(), t.Name(), "")
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) unusedIdentifiersPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.useRequestSettings(r)
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "unused",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildUnusedIdentifiersPage(w, ds.buildUnusedIdentifiersData())
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type UnusedIdentifiers struct {
+	Package   *code.Package
+	Resources []code.Resource
+}
+
+// Only the packages in the working directory are checked.
+func (ds *docServer) buildUnusedIdentifiersData() []UnusedIdentifiers {
+	var result []UnusedIdentifiers
+	for i, n := 0, ds.analyzer.NumPackages(); i < n; i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		if !ds.inWorkingDirectory(pkg.Directory) {
+			continue
+		}
+		if unuseds := ds.analyzer.UnusedExportedResources(pkg); len(unuseds) > 0 {
+			result = append(result, UnusedIdentifiers{Package: pkg, Resources: unuseds})
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Package.Path() < result[b].Package.Path()
+	})
+	return result
+}
+
+func (ds *docServer) buildUnusedIdentifiersPage(w http.ResponseWriter, unuseds []UnusedIdentifiers) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_UnusedExportedIdentifiers(), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeNone, "unused"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		page.Translation().Text_UnusedExportedIdentifiers(),
+	)
+
+	page.WriteString("<pre><code>")
+	page.WriteString(page.Translation().Text_UnusedExportedIdentifiersNote())
+	page.WriteString("\n")
+
+	if len(unuseds) == 0 {
+		page.WriteString("\n\t")
+		page.WriteString(page.Translation().Text_BlankList())
+		page.WriteString("\n")
+	}
+
+	for _, u := range unuseds {
+		page.WriteString("\n")
+		page.WriteString(`<span class="title">`)
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, u.Package.Path()}, page, u.Package.Path())
+		page.WriteString(page.Translation().Text_EnclosedInOarentheses(fmt.Sprint(len(u.Resources))))
+		page.WriteString(`</span>`)
+		for _, res := range u.Resources {
+			fmt.Fprintf(page, "\n\t%s ", resourceKind(res))
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, u.Package.Path()}, page, res.Name(), "name-"+res.Name())
+		}
+		page.WriteString("\n")
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+// "type", "func", "var" or "const".
+func resourceKind(res code.Resource) string {
+	switch res.(type) {
+	case *code.TypeName:
+		return "type"
+	case *code.Function:
+		return "func"
+	case *code.Variable:
+		return "var"
+	case *code.Constant:
+		return "const"
+	}
+	panic(fmt.Sprintf("unexpected resource: %T", res))
+}
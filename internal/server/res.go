@@ -1,6 +1,13 @@
 package server
 
 import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"text/template"
 	"time"
 
 	"golang.org/x/text/language"
@@ -10,9 +17,66 @@ import (
 	translation "go101.org/golds/internal/server/translations"
 )
 
+// The CSS of a theme is parsed as a text/template, in which
+// {{ .Colon }} and {{ .Fonts }} are available. The CSS classes and ids
+// which the pages rely on are listed in theme.RequiredSelectors.
+// The names of custom themes should not be the same as the built-in ones.
 type Theme interface {
 	Name() string
 	CSS() string
+	ChartColors() (background, foreground string)
+}
+
+// loadCustomThemes returns the themes specified by options.ThemeFile.
+func loadCustomThemes(options PageOutputOptions) []Theme {
+	if options.ThemeFile == "" {
+		return nil
+	}
+	t, err := loadThemeFile(options.ThemeFile)
+	if err != nil {
+		log.Fatalf("Load theme file (%s) error: %s", options.ThemeFile, err)
+	}
+	return []Theme{t}
+}
+
+// The custom theme is used initially if no themes are specified.
+func initialThemeName(options PageOutputOptions, customThemes []Theme) string {
+	if options.Theme == "" && len(customThemes) > 0 {
+		return customThemes[0].Name()
+	}
+	return options.Theme
+}
+
+// loadThemeFile creates a custom theme from a CSS file.
+// The theme name is the file name without the extension.
+func loadThemeFile(path string) (Theme, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	css := string(data)
+	if _, err := template.New("css").Parse(css); err != nil {
+		return nil, fmt.Errorf("parse css template error: %s", err)
+	}
+	for _, sel := range theme.MissingSelectors(css) {
+		log.Printf("Warning: the custom theme (%s) doesn't style %s.", path, sel)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9', r == '-', r == '_':
+			return r
+		case 'A' <= r && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, name)
+	switch name {
+	case "", (&theme.Light{}).Name(), (&theme.Dark{}).Name():
+		name = "custom-" + name
+	}
+	return theme.NewCustom(name, css), nil
 }
 
 type Translation interface {
@@ -27,9 +91,12 @@ type Translation interface {
 	Text_EnclosedInOarentheses(text string) string
 	Text_PreferredFontList() string
 	Text_BlankList() string
+	Text_Theme() string    // used in page headers
+	Text_Language() string // used in page headers
 
 	// server
 	Text_Server_Started() string
+	Text_Server_SourceChanged() string
 
 	// analyzing
 	Text_Analyzing() string
@@ -52,15 +119,16 @@ type Translation interface {
 	Text_Analyzing_CollectSourceFiles(d time.Duration) string
 	Text_Analyzing_CollectObjectReferences(d time.Duration) string
 	Text_Analyzing_CacheSourceFiles(d time.Duration) string
+	Text_Analyzing_CollectExamples(d time.Duration) string
 
 	// overview page
 	Text_Overview() string
 	Text_PackageList() string
 	Text_StatisticsWithMoreLink(detailedStatsLink string) string
 	Text_SimpleStats(stats *code.Stats) string
-	Text_Modules() string                                    // to use
-	Text_BelongingModule() string                            // to use
-	Text_RequireStat(numRequires, numRequiredBys int) string // to use
+	Text_Modules() string
+	Text_BelongingModule() string                            // also used in package details page
+	Text_RequireStat(numRequires, numRequiredBys int) string // also used in module page
 	Text_UpdateTip(tipName string) string                    // tip names: "ToUpdate", "Updating", "Updated"
 
 	Text_SortBy() string                // also used in other pages
@@ -68,6 +136,17 @@ type Translation interface {
 	Text_SortByItem(by string) string   // also used in other pages
 	Text_FilterItem(fltr string) string // also used in other pages
 
+	// search page
+	Text_Search() string
+
+	// module page
+	Text_Module(modulePath string) string
+	Text_ModulePath() string
+	Text_Version() string
+	Text_ReplacedBy() string
+	Text_Requires() string
+	Text_RequiredBy() string
+
 	// package details page
 	Text_Package(pkgPath string) string
 	Text_BelongingPackage() string // also used in source code page
@@ -88,25 +167,51 @@ type Translation interface {
 	Text_AsInputsOf(num int) string
 	Text_AsTypesOf(num int) string
 	Text_References(num int) string
+	Text_Examples(num int) string
+	Text_TypeSet() string
+	Text_InheritedFrom() string
+	Text_Deprecated() string
+	Text_OnlyOnPlatforms(platforms string) string
+	Text_DeclarationsOnOtherPlatforms(num int) string
+	Text_UsedByPackages(numPkgs, numUses int) string
 
 	// package dependencies page
 	Text_DependencyRelations(pkgPath string) string // also used in package details page with a blank argument.
 	Text_Imports() string
 	Text_ImportedBy() string
+	Text_UsedExportedIdentifiers() string
+	Text_ImportViolations() string
+	Text_ImportViolationsWithDetailsLink(count int, detailsLink string) string
+	Text_ForbiddenByImportRule(line int, rule string) string
+	Text_DependencyGraph() string
+	Text_DependencyGraphOption(option string) string
 
 	// method implementation page
 	Text_MethodImplementations() string
 	Text_NumMethodsImplementingNothing(count int) string
 
+	// type hierarchy page
+	Text_TypeHierarchy() string // also used in package details page.
+	Text_EmbeddingTree(forInterface bool) string
+
+	// function calls page
+	Text_FunctionCalls() string
+	Text_Callers(num int) string
+	Text_Callees(num int) string
+	Text_ThroughInterfaceMethod(method string) string
+
 	// object references(uses) page
 	Text_ReferenceList() string
 	Text_ObjectKind(kind string) string
 	Text_ObjectUses(num int) string // also used in other pages
+	Text_DeprecatedUses(num int) string
 
 	// source code page
 	Text_SourceCode(pkgPath, bareFilename string) string
 	Text_SourceFilePath() string
 	Text_GeneratedFrom() string
+	Text_InterfaceMethodCallImplementations() string
+	Text_SourceLine(line int) string
 
 	// statistics
 	Text_Statistics() string
@@ -117,6 +222,15 @@ type Translation interface {
 	Text_ValueStatistics(values map[string]interface{}) string
 	Text_Othertatistics(values map[string]interface{}) string
 
+	// unused identifiers page
+	Text_UnusedExportedIdentifiers() string
+	Text_UnusedExportedIdentifiersNote() string
+
+	// deprecated API uses page
+	Text_DeprecatedAPIUses() string
+	Text_DeprecatedAPIUsesNote() string
+	Text_DeprecatedAPIUsesWithDetailsLink(count int, detailsLink string) string // used in overview page
+
 	// Footer
 	Text_GeneratedPageFooter(goldsVersion, qrCodeLink, goOS, goArch string) string
 	Text_GeneratedPageFooterSimple(goldsVersion, goOS, goArch string) string
@@ -129,28 +243,97 @@ func (ds *docServer) currentSettings() (Theme, Translation) {
 	return ds.currentTheme, ds.currentTranslation
 }
 
+// changeSettings changes the default settings.
+// It should be only called at init phase.
 func (ds *docServer) changeSettings(themeName string, langTags ...string) {
 	ds.mutex.Lock()
 	defer ds.mutex.Unlock()
 
 	if themeName != "" {
-		ds.currentTheme = ds.themeByName(themeName)
+		ds.defaultTheme = ds.themeByName(themeName)
 	}
 	if len(langTags) > 0 {
-		ds.currentTranslation = ds.translationByLangs(langTags...)
+		ds.defaultTranslation = ds.translationByLangs(langTags...)
 	}
+	ds.currentTheme, ds.currentTranslation = ds.defaultTheme, ds.defaultTranslation
 }
 
-func (ds *docServer) changeTranslationByAcceptLanguage(acceptedLanguage string) {
-	langTags, _, _ := language.ParseAcceptLanguage(acceptedLanguage)
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
-	ds.currentTranslation = ds.translationByLangTags(langTags...)
+// Settings are stored in cookies per client, so that different people
+// can view the docs served by one server in different languages.
+const (
+	themeCookieName       = "golds-theme"
+	translationCookieName = "golds-lang"
+)
+
+// requestSettings decides the settings used to serve a request.
+// The settings in cookies take precedence over the Accept-Language
+// header, which takes precedence over the default settings.
+// ds needs not to be locked, for the fields used here are only
+// modified at init phase.
+func (ds *docServer) requestSettings(r *http.Request) (Theme, Translation) {
+	theme, translation := ds.defaultTheme, ds.defaultTranslation
+	if genDocsMode {
+		return theme, translation
+	}
+
+	if c, err := r.Cookie(themeCookieName); err == nil {
+		theme = ds.themeByName(c.Value)
+	}
+	if c, err := r.Cookie(translationCookieName); err == nil {
+		translation = ds.translationByLangs(c.Value)
+	} else if !ds.fixedDefaultLang {
+		langTags, _, _ := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+		translation = ds.translationByLangTags(langTags...)
+	}
+	return theme, translation
+}
+
+// useRequestSettings should be called in request handlers just after
+// ds is locked, before ds.currentTheme and ds.currentTranslation are used.
+// The settings are also parts of page cache keys (see cachePage).
+func (ds *docServer) useRequestSettings(r *http.Request) {
+	ds.currentTheme, ds.currentTranslation = ds.requestSettings(r)
+	ds.snapshotting = isSnapshotRequest(r)
+}
+
+// changeRequestSettings handles the "theme" and "lang" query parameters.
+// The specified settings are stored in cookies, then the client is
+// redirected to the URL without these parameters.
+// Return false if there are no such parameters.
+func (ds *docServer) changeRequestSettings(w http.ResponseWriter, r *http.Request) bool {
+	query := r.URL.Query()
+	themeName, lang := query.Get("theme"), query.Get("lang")
+	if themeName == "" && lang == "" {
+		return false
+	}
+
+	if themeName != "" {
+		http.SetCookie(w, settingCookie(themeCookieName, ds.themeByName(themeName).Name()))
+		query.Del("theme")
+	}
+	if lang != "" {
+		http.SetCookie(w, settingCookie(translationCookieName, ds.translationByLangs(lang).LangTag()))
+		query.Del("lang")
+	}
+
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	http.Redirect(w, r, u.String(), http.StatusTemporaryRedirect)
+	return true
+}
+
+func settingCookie(name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:   name,
+		Value:  value,
+		Path:   "/",
+		MaxAge: 365 * 24 * 3600,
+	}
 }
 
 // All themes and translations must be registered at init phase,
 // so that no syncrhomization is needed.
-func (ds *docServer) initSettings(lang string) {
+func (ds *docServer) initSettings(lang string, customThemes ...Theme) {
 	var (
 		themes        = make([]Theme, 0, 2)
 		translations  = make([]Translation, 0, 6)
@@ -169,6 +352,10 @@ func (ds *docServer) initSettings(lang string) {
 	}
 
 	registerTheme(&theme.Light{})
+	registerTheme(&theme.Dark{})
+	for _, t := range customThemes {
+		registerTheme(t)
+	}
 
 	registerTranslation(&translation.English{})
 	registerTranslation(&translation.Chinese{})
@@ -181,13 +368,26 @@ func (ds *docServer) initSettings(lang string) {
 	ds.langMatcher = language.NewMatcher(langTags)
 	ds.translationsByLangTagIndex = translations2
 
-	ds.currentTheme = ds.allThemes[0]
-	ds.currentTranslation = ds.allTranslations[0]
-	ds.currentTranslation = ds.translationByLangs(lang)
+	ds.defaultTheme = ds.allThemes[0]
+	ds.defaultTranslation = ds.allTranslations[0]
+	ds.defaultTranslation = ds.translationByLangs(lang)
+	ds.currentTheme, ds.currentTranslation = ds.defaultTheme, ds.defaultTranslation
+
+	allThemeNames = make([]string, len(themes))
+	for i, t := range themes {
+		allThemeNames[i] = t.Name()
+	}
+	allTranslationNames = make([]string, len(translations))
+	allTranslationLangTags = make([]string, len(translations))
+	for i, tr := range translations {
+		allTranslationNames[i] = tr.Name()
+		allTranslationLangTags[i] = tr.LangTag()
+	}
 }
 
+// Used out of request handlers, such as in console logs.
 func (ds *docServer) currentTranslationSafely() Translation {
-	return ds.currentTranslation
+	return ds.defaultTranslation
 }
 
 func (ds *docServer) themeByName(name string) Theme {
@@ -224,7 +424,7 @@ func (ds *docServer) translationByLangs(langs ...string) Translation {
 
 func (ds *docServer) translationByLangTags(userPrefs ...language.Tag) Translation {
 	if len(userPrefs) == 0 {
-		return ds.currentTranslation
+		return ds.defaultTranslation
 	}
 
 	_, index, confidence := ds.langMatcher.Match(userPrefs...)
@@ -11,7 +11,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"golang.org/x/text/language"
@@ -50,6 +49,10 @@ type docServer struct {
 	analyzingLogger *log.Logger
 	analyzingLogs   []LoadingLogMessage
 
+	// Return false instead of exiting the program when the packages
+	// to analyze have errors (see tryAnalyzing).
+	noExitOnErrors bool
+
 	// Cached pages
 	//theCSSFile                cssFile
 	//theOverviewPage           *overviewPage
@@ -62,7 +65,23 @@ type docServer struct {
 	cachedPages        map[pageCacheKey][]byte
 	cachedPagesOptions map[pageCacheKey]interface{} // key.options must be nil in this map
 
-	//
+	// Built when it is used for the first time.
+	searchIndex *searchIndex
+
+	// Rendered pages cached on disk. Both are blank if disk cache is disabled.
+	pageCacheDir string
+	pageCache    *diskPageCache
+
+	// Whether or not the current request is made by snapshotPages.
+	// Set together with currentTheme and currentTranslation.
+	snapshotting bool
+
+	// Used when requests specify no settings. Only modified at init phase.
+	defaultTheme       Theme
+	defaultTranslation Translation
+	fixedDefaultLang   bool // Accept-Language headers are ignored if it is true
+
+	// The settings of the request being served (see useRequestSettings).
 	currentTheme       Theme
 	currentTranslation Translation
 
@@ -75,7 +94,6 @@ type docServer struct {
 
 	//
 	generalLogger *log.Logger
-	visited       int32
 }
 
 func Run(options PageOutputOptions, args []string, recommendedPort string, silentMode bool, printUsage func(io.Writer), appPkgPath string, roughBuildTime func() time.Time) {
@@ -96,13 +114,18 @@ func Run(options PageOutputOptions, args []string, recommendedPort string, silen
 		roughBuildTime: roughBuildTime,
 	}
 
+	if options.CachePagesOnDisk {
+		ds.pageCacheDir = defaultPageCacheDir()
+	}
+
+	customThemes := loadCustomThemes(options)
 	if options.PreferredLang != "" {
-		ds.visited = 1
-		//ds.changeTranslationByAcceptLanguage(options.PreferredLang)
-		ds.initSettings(options.PreferredLang)
+		ds.fixedDefaultLang = true
+		ds.initSettings(options.PreferredLang, customThemes...)
 	} else {
-		ds.initSettings(os.Getenv("LANG"))
+		ds.initSettings(os.Getenv("LANG"), customThemes...)
 	}
+	ds.changeSettings(initialThemeName(options, customThemes))
 
 	port, delta := recommendedPort, -1
 	defaultPort, err := strconv.Atoi(recommendedPort)
@@ -135,11 +158,19 @@ NextTry:
 		log.Fatal(err)
 	}
 
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
 	go func() {
-		ds.analyze(args, printUsage)
+		ds.analyze(args, options, printUsage)
 		ds.analyzingLogger.SetPrefix("")
 		serverStarted := ds.currentTranslationSafely().Text_Server_Started()
 		ds.analyzingLogger.Printf("%s http://localhost:%v\n", serverStarted, port)
+
+		if options.WatchSourceChanges {
+			ds.watchSourceChanges(args, options)
+		}
 	}()
 
 	if !silentMode {
@@ -162,14 +193,21 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	sem <- struct{}{}
 	defer func() { <-sem }()
 
-	if atomic.SwapInt32(&ds.visited, 1) == 0 {
-		ds.changeTranslationByAcceptLanguage(r.Header.Get("Accept-Language"))
+	// Query strings might contain setting change parameters,
+	// such as "?theme=dark&lang=zh-CN".
+	if !genDocsMode && ds.changeRequestSettings(w, r) {
+		return
 	}
 
-	// Query strings might contain setting change parameters,
-	// such as "?theme=dark&lang=fr".
-	// ToDo, if query string is not blank, change settings,
-	//       then redirect to the url without query string.
+	if isDiskCacheablePage(r) {
+		if ds.serveDiskCachedPage(w, r) {
+			return
+		}
+		if pr := ds.newPageRecorder(w, r); pr != nil {
+			defer ds.recordPage(pr, r)
+			w = pr
+		}
+	}
 
 	var path = r.URL.Path[1:]
 	if path == "" {
@@ -187,6 +225,14 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
 		case "statistics":
 			ds.statisticsPage(w, r)
+		case "unused":
+			ds.unusedIdentifiersPage(w, r)
+		case "import-violations":
+			ds.importViolationsPage(w, r)
+		case "deprecated":
+			ds.deprecatedUsesPage(w, r)
+		case "search":
+			ds.searchPage(w, r)
 		}
 		return
 	}
@@ -204,17 +250,23 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			ds.updateAPI(w, r)
 		case "load":
 			ds.loadAPI(w, r)
+		case "search":
+			ds.searchAPI(w, r)
 		}
 	case ResTypeCSS: // "css"
 		ds.cssFile(w, r, removeVersionFromFilename(resPath, goldsVersion))
 	case ResTypeJS: // "jvs"
-		ds.javascriptFile(w, r, removeVersionFromFilename(resPath, goldsVersion))
+		if resPath == "search-index" {
+			ds.searchIndexJavascriptFile(w, r)
+		} else {
+			ds.javascriptFile(w, r, removeVersionFromFilename(resPath, goldsVersion))
+		}
 	case ResTypeSVG: // "svg"
 		ds.svgFile(w, r, resPath)
 	case ResTypePNG: // "png"
 		ds.pngFile(w, r, resPath)
-	//case "mod:": // module
-	//	ds.modulePage(w, r, path)
+	case ResTypeModule: // "mod"
+		ds.modulePage(w, r, resPath)
 	case ResTypePackage: // "pkg"
 		ds.packageDetailsPage(w, r, resPath)
 	case ResTypeDependency: // "dep"
@@ -237,6 +289,14 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		} else {
 			ds.methodImplementationPage(w, r, resPath[:index], resPath[index+len(sep):])
 		}
+	case ResTypeHierarchy: // "hie"
+		const sep = "."
+		index := strings.LastIndex(resPath, sep)
+		if index < 0 {
+			fmt.Fprint(w, "Type containing package is not specified")
+		} else {
+			ds.typeHierarchyPage(w, r, resPath[:index], resPath[index+len(sep):])
+		}
 	case ResTypeReference: // "ref"
 		// resPath doesn't contian unexported selectors with their package path prefixes for sure.
 		// Two forms: pkg..id or pkg..type.selector.
@@ -249,16 +309,53 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		} else {
 			ds.identifierReferencePage(w, r, resPath[:index], resPath[index+len(sep):])
 		}
+	case ResTypeCall: // "cal"
+		// Two forms: pkg..function or pkg..type.method.
+		const sep = ".."
+		index := strings.LastIndex(resPath, sep)
+		if index < 0 {
+			fmt.Fprint(w, "Function containing package is not specified")
+		} else {
+			ds.functionCallsPage(w, r, resPath[:index], resPath[index+len(sep):])
+		}
 	}
 }
 
 var sem = make(chan struct{}, 10)
 
-func (ds *docServer) analyze(args []string, printUsage func(io.Writer)) {
+func (ds *docServer) analyze(args []string, options PageOutputOptions, printUsage func(io.Writer)) {
+	if !ds.tryAnalyzing(args, options) {
+		if printUsage != nil {
+			printUsage(os.Stdout)
+		}
+		os.Exit(1)
+	}
+}
+
+// tryAnalyzing is used by the callers which need to clean up
+// before exiting. ds.noExitOnErrors should be set for such callers.
+func (ds *docServer) tryAnalyzing(args []string, options PageOutputOptions) bool {
 	ds.workingDirectory, _ = os.Getwd()
 
+	if len(args) == 0 {
+		args = []string{"."}
+	} else if len(args) == 1 && args[0] == "std" {
+		os.Setenv("GO111MODULE", "off")
+		os.Setenv("CGO_ENABLED", "0")
+	}
+
+	return ds.analyzePackages(args, options)
+}
+
+// analyzePackages is also used to re-analyze packages in watching mode.
+// The current analysis result keeps being used to serve pages until
+// the new one is ready. Then the new one replaces the old one atomically.
+func (ds *docServer) analyzePackages(args []string, options PageOutputOptions) (ok bool) {
 	var stopWatch = util.NewStopWatch()
 	defer func() {
+		if !ok {
+			return
+		}
 		d := stopWatch.Duration(false)
 		memUsed := util.MemoryUse()
 		ds.registerAnalyzingLogMessage(func() string {
@@ -267,22 +364,26 @@ func (ds *docServer) analyze(args []string, printUsage func(io.Writer)) {
 		ds.registerAnalyzingLogMessage(func() string { return "" })
 	}()
 
-	if len(args) == 0 {
-		args = []string{"."}
-	} else if len(args) == 1 && args[0] == "std" {
-		os.Setenv("GO111MODULE", "off")
-		os.Setenv("CGO_ENABLED", "0")
-	}
-
 	ds.registerAnalyzingLogMessage(func() string {
 		return ds.currentTranslationSafely().Text_Analyzing_Start()
 	})
 
-	if !ds.analyzer.ParsePackages(ds.onAnalyzingSubTaskDone, args...) {
-		if printUsage != nil {
-			printUsage(os.Stdout)
-		}
-		os.Exit(1)
+	// Code might be changed during the analysis. The pages rendered from
+	// the analysis result are cached on disk only if it is verified not.
+	var fingerprint string
+	if ds.pageCacheDir != "" {
+		fingerprint = ds.preparePageCache(args, options)
+	}
+
+	var analyzer = &code.CodeAnalyzer{}
+
+	parseOptions := code.ParseOptions{
+		Tests:          options.IncludeTests,
+		NoExitOnErrors: ds.phase == Phase_Analyzed || ds.noExitOnErrors, // re-analyzing, or diffing APIs
+		Platforms:      options.Platforms,
+	}
+	if !analyzer.ParsePackagesWithOptions(ds.onAnalyzingSubTaskDone, parseOptions, args...) {
+		return false
 	}
 
 	//{
@@ -291,11 +392,29 @@ func (ds *docServer) analyze(args []string, printUsage func(io.Writer)) {
 	//	ds.mutex.Unlock()
 	//}
 
-	ds.analyzer.AnalyzePackages(ds.onAnalyzingSubTaskDone)
+	analyzer.AnalyzePackages(ds.onAnalyzingSubTaskDone)
+
+	if options.ImportRules != nil {
+		analyzer.CheckImportRules(options.ImportRules)
+	}
+
+	if fingerprint != "" {
+		if fp, err := pageCacheFingerprint(args, options); err != nil {
+			log.Println("calculate packages fingerprint error:", err)
+			fingerprint = ""
+		} else if fp != fingerprint {
+			log.Println("code changed during analysis, the pages will not be cached on disk")
+			fingerprint = ""
+		}
+	}
 
 	{
 		ds.mutex.Lock()
+		ds.analyzer = analyzer
 		ds.phase = Phase_Analyzed
+		if fingerprint != "" && ds.startRecordingPages(fingerprint) {
+			go ds.snapshotPages(analyzer)
+		}
 		//ds.packagePages = make(map[string]packagePage, ds.analyzer.NumPackages())
 		//ds.implPages = make(map[implPageKey][]byte, ds.analyzer.RoughTypeNameCount())
 		//ds.identifierReferencesPages = make(map[usePageKey][]byte, ds.analyzer.RoughExportedIdentifierCount())
@@ -309,6 +428,9 @@ func (ds *docServer) analyze(args []string, printUsage func(io.Writer)) {
 			int(ds.analyzer.RoughExportedIdentifierCount())
 		ds.cachedPages = make(map[pageCacheKey][]byte, int(n))
 		ds.cachedPagesOptions = make(map[pageCacheKey]interface{}, ds.analyzer.NumPackages())
+		ds.searchIndex = nil
 		ds.mutex.Unlock()
 	}
+
+	return true
 }
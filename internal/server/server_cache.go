@@ -0,0 +1,529 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go101.org/golds/code"
+)
+
+// If the -cache option is specified, a snapshot of the docs site is
+// cached on disk. After an analysis finishes, the pages reachable from
+// the overview page are all rendered in background (see snapshotPages)
+// and recorded in the cache file of the project, which is keyed by the
+// fingerprint of the analyzed packages (args, GOOS/GOARCH, Go version
+// and the hashes of all the involved files, see code.PackagesFingerprint).
+// In a subsequent run on unchanged code, the snapshot pages are served
+// immediately. The analysis still runs in background, for the pages
+// not in the snapshot (such as the overview page and the search page).
+//
+// The pages rendered from an analysis result are recorded only if the
+// fingerprints calculated before and after the analysis are identical,
+// so that the recorded pages always match the fingerprint of the file.
+//
+// The cache file format:
+//
+//	header line: "golds-page-cache <version> <golds version> <fingerprint>\n"
+//	records:     (uvarint length + bytes) x 3, for key, content type and content.
+//
+// A record with a blank key marks that the snapshot is finished.
+const pageCacheFormatVersion = "1"
+
+const (
+	// No more pages are recorded when a cache file reaches this size.
+	maxPageCacheFileSize = 256 << 20
+
+	// Cache files of the least recently used projects are removed
+	// if there are more cache files than this in the cache directory.
+	maxPageCacheFiles = 8
+)
+
+type diskCachedPage struct {
+	contentType string
+	content     []byte
+}
+
+type diskPageCache struct {
+	filename    string
+	fingerprint string
+
+	// The pages loaded from the cache file. They are served
+	// before the first analysis finishes, then discarded.
+	pages map[string]diskCachedPage
+
+	// Whether or not the snapshot in the cache file is finished
+	// (either all the pages are recorded or the file is full).
+	snapshotted bool
+
+	// Whether or not the pages rendered from the current analysis
+	// result are being recorded in the cache file.
+	recording bool
+	file      *os.File // opened in appending mode
+	size      int64
+	recorded  map[string]bool
+}
+
+func defaultPageCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		log.Println("find user cache directory error:", err)
+		return ""
+	}
+	return filepath.Join(dir, "golds")
+}
+
+// Different projects use different cache files.
+func pageCacheFilename(cacheDir, workingDirectory string, args []string) string {
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s\n%q", workingDirectory, args)))
+	return filepath.Join(cacheDir, fmt.Sprintf("%x.pages", key[:12]))
+}
+
+// Import rules also change the content of some pages.
+func pageCacheFingerprint(args []string, options PageOutputOptions) (string, error) {
+	fingerprint, err := code.PackagesFingerprint(code.ParseOptions{Tests: options.IncludeTests, Platforms: options.Platforms}, args...)
+	if err != nil || options.ImportRules == nil {
+		return fingerprint, err
+	}
+	var rules bytes.Buffer
+	for _, r := range options.ImportRules {
+		fmt.Fprintf(&rules, "%d: %s\n", r.Line, r)
+	}
+	return fmt.Sprintf("%s-%x", fingerprint, sha256.Sum256(rules.Bytes())), nil
+}
+
+// preparePageCache should be called before analyzing packages.
+// It stops recording pages (the current analysis result will be
+// replaced) and returns the fingerprint of the packages to analyze.
+// The cached pages are loaded when it is called for the first time.
+// Any errors only cause the disk cache not used.
+func (ds *docServer) preparePageCache(args []string, options PageOutputOptions) string {
+	ds.mutex.Lock()
+	cache := ds.pageCache
+	if cache != nil {
+		cache.recording = false
+	}
+	ds.mutex.Unlock()
+
+	fingerprint, err := pageCacheFingerprint(args, options)
+	if err != nil {
+		log.Println("calculate packages fingerprint error:", err)
+		return ""
+	}
+	if cache != nil {
+		return fingerprint
+	}
+
+	if err := os.MkdirAll(ds.pageCacheDir, 0700); err != nil {
+		log.Println("create page cache directory error:", err)
+		return ""
+	}
+
+	filename := pageCacheFilename(ds.pageCacheDir, ds.workingDirectory, args)
+	removeLeastRecentlyUsedPageCacheFiles(ds.pageCacheDir, filename)
+	pages, snapshotted, err := readPageCacheFile(filename, fingerprint)
+	if err != nil {
+		log.Println("read page cache error:", err)
+	}
+
+	ds.mutex.Lock()
+	ds.pageCache = &diskPageCache{
+		filename:    filename,
+		fingerprint: fingerprint,
+		pages:       pages,
+		snapshotted: snapshotted,
+	}
+	ds.mutex.Unlock()
+	return fingerprint
+}
+
+// The modification time of a cache file is updated when it is used.
+func removeLeastRecentlyUsedPageCacheFiles(cacheDir, current string) {
+	infos, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		log.Println("read page cache directory error:", err)
+		return
+	}
+	var files = make([]os.FileInfo, 0, len(infos))
+	for _, info := range infos {
+		name := filepath.Join(cacheDir, info.Name())
+		if !info.IsDir() && strings.HasSuffix(name, ".pages") && name != current {
+			files = append(files, info)
+		}
+	}
+	if len(files) < maxPageCacheFiles {
+		return
+	}
+	sort.Slice(files, func(a, b int) bool {
+		return files[a].ModTime().After(files[b].ModTime())
+	})
+	for _, info := range files[maxPageCacheFiles-1:] {
+		if err := os.Remove(filepath.Join(cacheDir, info.Name())); err != nil {
+			log.Println("remove page cache file error:", err)
+		}
+	}
+}
+
+// Return nil pages if the file doesn't exist or it is outdated.
+func readPageCacheFile(filename, fingerprint string) (pages map[string]diskCachedPage, snapshotted bool, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, false, nil
+	}
+	if header != pageCacheFileHeader(fingerprint) {
+		return nil, false, nil
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(filename, now, now); err != nil {
+		log.Println("touch page cache file error:", err)
+	}
+
+	pages = make(map[string]diskCachedPage, 1024)
+	readBytes := func() ([]byte, error) {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxPageCacheFileSize {
+			return nil, fmt.Errorf("invalid record length: %d", n)
+		}
+		bs := make([]byte, n)
+		_, err = io.ReadFull(r, bs)
+		return bs, err
+	}
+	for {
+		key, err := readBytes()
+		if err != nil {
+			// EOF, or the last record is incomplete.
+			break
+		}
+		contentType, err := readBytes()
+		if err != nil {
+			break
+		}
+		content, err := readBytes()
+		if err != nil {
+			break
+		}
+		if len(key) == 0 {
+			snapshotted = true
+			continue
+		}
+		pages[string(key)] = diskCachedPage{string(contentType), content}
+	}
+	return pages, snapshotted, nil
+}
+
+func pageCacheFileHeader(fingerprint string) string {
+	return fmt.Sprintf("golds-page-cache %s %s %s\n", pageCacheFormatVersion, goldsVersion, fingerprint)
+}
+
+// startRecordingPages is called (with ds.mutex locked) when a new
+// analysis result is used. fingerprint must be the one of the packages
+// analyzed for the result, which is verified to be unchanged during
+// the analysis. It returns whether or not the snapshot needs to be made.
+func (ds *docServer) startRecordingPages(fingerprint string) (snapshot bool) {
+	cache := ds.pageCache
+	if cache == nil {
+		return false
+	}
+
+	if fingerprint != cache.fingerprint || cache.recorded == nil {
+		var err error
+		if fingerprint == cache.fingerprint && cache.pages != nil {
+			// Continue appending to the loaded cache file.
+			cache.recorded = make(map[string]bool, len(cache.pages))
+			for key := range cache.pages {
+				cache.recorded[key] = true
+			}
+			cache.file, err = os.OpenFile(cache.filename, os.O_WRONLY|os.O_APPEND, 0600)
+			if err == nil {
+				var info os.FileInfo
+				if info, err = cache.file.Stat(); err == nil {
+					cache.size = info.Size()
+				}
+			}
+		} else {
+			cache.fingerprint = fingerprint
+			err = cache.reset()
+		}
+		if err != nil {
+			log.Println("open page cache file error:", err)
+			cache.close()
+			return false
+		}
+	}
+	cache.pages = nil // only needed before the first analysis finishes
+	cache.recording = cache.file != nil
+	return cache.recording && !cache.snapshotted
+}
+
+// Truncate the cache file and write the header.
+func (cache *diskPageCache) reset() error {
+	cache.close()
+	f, err := os.OpenFile(cache.filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	cache.file = f
+	cache.recorded = make(map[string]bool, 1024)
+	cache.snapshotted = false
+	n, err := f.WriteString(pageCacheFileHeader(cache.fingerprint))
+	cache.size = int64(n)
+	return err
+}
+
+func (cache *diskPageCache) close() {
+	if cache.file != nil {
+		cache.file.Close()
+		cache.file = nil
+	}
+	cache.recording = false
+	cache.recorded = nil
+}
+
+func (cache *diskPageCache) add(key string, page diskCachedPage) {
+	if !cache.recording || cache.recorded[key] {
+		return
+	}
+	record := encodePageCacheRecord(key, page)
+	if cache.size+int64(len(record)) > maxPageCacheFileSize {
+		cache.recording = false
+		return
+	}
+	if cache.write(record) {
+		cache.recorded[key] = true
+	}
+}
+
+// finishSnapshot appends the finish mark, even if the file is full.
+func (cache *diskPageCache) finishSnapshot() {
+	if cache.file == nil || cache.snapshotted {
+		return
+	}
+	if cache.write(encodePageCacheRecord("", diskCachedPage{})) {
+		cache.snapshotted = true
+	}
+}
+
+func (cache *diskPageCache) write(record []byte) bool {
+	if _, err := cache.file.Write(record); err != nil {
+		log.Println("write page cache error:", err)
+		cache.close()
+		return false
+	}
+	cache.size += int64(len(record))
+	return true
+}
+
+func encodePageCacheRecord(key string, page diskCachedPage) []byte {
+	var buf bytes.Buffer
+	var lenBuf [binary.MaxVarintLen64]byte
+	for _, bs := range [][]byte{[]byte(key), []byte(page.contentType), page.content} {
+		n := binary.PutUvarint(lenBuf[:], uint64(len(bs)))
+		buf.Write(lenBuf[:n])
+		buf.Write(bs)
+	}
+	return buf.Bytes()
+}
+
+// Pages depending on query strings which are not page options
+// and the pages which are not pure functions of the analysis
+// results are not cached on disk. The overview page shows the
+// state of updating Golds.
+func isDiskCacheablePage(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	path := r.URL.Path
+	switch {
+	case path == "/", path == "/update", path == "/search":
+		return false
+	case strings.HasPrefix(path, "/"+string(ResTypeAPI)+":"):
+		return false
+	}
+	return true
+}
+
+func (ds *docServer) diskCachedPageKey(r *http.Request) string {
+	theme, translation := ds.requestSettings(r)
+	return theme.Name() + " " + translation.Name() + " " + r.URL.RequestURI()
+}
+
+// Return false if the page is not cached.
+func (ds *docServer) serveDiskCachedPage(w http.ResponseWriter, r *http.Request) bool {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.pageCache == nil || ds.phase >= Phase_Analyzed {
+		return false
+	}
+	page, ok := ds.pageCache.pages[ds.diskCachedPageKey(r)]
+	if !ok {
+		return false
+	}
+	w.Header().Set("Content-Type", page.contentType)
+	w.Write(page.content)
+	return true
+}
+
+type pageRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	content    bytes.Buffer
+
+	// The pages rendered from an old analysis result are not recorded.
+	analyzer *code.CodeAnalyzer
+}
+
+func (pr *pageRecorder) WriteHeader(statusCode int) {
+	pr.statusCode = statusCode
+	pr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (pr *pageRecorder) Write(data []byte) (int, error) {
+	pr.content.Write(data)
+	return pr.ResponseWriter.Write(data)
+}
+
+// Return nil if the page needs not to be recorded.
+func (ds *docServer) newPageRecorder(w http.ResponseWriter, r *http.Request) *pageRecorder {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.pageCache == nil || !ds.pageCache.recording || ds.phase < Phase_Analyzed {
+		return nil
+	}
+	return &pageRecorder{ResponseWriter: w, statusCode: http.StatusOK, analyzer: ds.analyzer}
+}
+
+func (ds *docServer) recordPage(pr *pageRecorder, r *http.Request) {
+	if pr.statusCode != http.StatusOK {
+		return
+	}
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.pageCache == nil || pr.analyzer != ds.analyzer {
+		return
+	}
+	ds.pageCache.add(ds.diskCachedPageKey(r), diskCachedPage{
+		contentType: pr.Header().Get("Content-Type"),
+		content:     pr.content.Bytes(),
+	})
+}
+
+// The key of the context value to mark the requests made by snapshotPages.
+type snapshotRequestKey struct{}
+
+// The rendered pages of snapshot requests are not cached in memory.
+func isSnapshotRequest(r *http.Request) bool {
+	return r.Context().Value(snapshotRequestKey{}) != nil
+}
+
+// Links and resources (CSS, JavaScript and images) in pages.
+var pageLinkRegexp = regexp.MustCompile(`(?:href|src)="(/[^/"][^"]*)"`)
+
+// snapshotPages renders all the pages reachable from the overview page,
+// so that they are recorded in the cache file. It stops when a new
+// analysis result is used or the cache file is full.
+func (ds *docServer) snapshotPages(analyzer *code.CodeAnalyzer) {
+	ctx := context.WithValue(context.Background(), snapshotRequestKey{}, true)
+	var queue = []string{"/"}
+	var visited = map[string]bool{"/": true}
+	for len(queue) > 0 {
+		ds.mutex.Lock()
+		stop := ds.analyzer != analyzer || ds.pageCache == nil || !ds.pageCache.recording
+		ds.mutex.Unlock()
+		if stop {
+			break
+		}
+
+		uri := queue[0]
+		queue = queue[1:]
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			continue
+		}
+		w := &snapshotResponseWriter{header: make(http.Header), statusCode: http.StatusOK}
+		ds.ServeHTTP(w, r)
+		if w.statusCode != http.StatusOK || !strings.HasPrefix(w.header.Get("Content-Type"), "text/html") {
+			continue
+		}
+		for _, m := range pageLinkRegexp.FindAllSubmatch(w.content.Bytes(), -1) {
+			link := html.UnescapeString(string(m[1]))
+			if i := strings.IndexByte(link, '#'); i >= 0 {
+				link = link[:i]
+			}
+			if !visited[link] {
+				visited[link] = true
+				if isSnapshotLink(link) {
+					queue = append(queue, link)
+				}
+			}
+		}
+	}
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	if ds.analyzer == analyzer && ds.pageCache != nil {
+		ds.pageCache.finishSnapshot()
+	}
+}
+
+// The links changing settings are not followed.
+func isSnapshotLink(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	if query := u.Query(); query.Get("theme") != "" || query.Get("lang") != "" {
+		return false
+	}
+	return isDiskCacheablePage(&http.Request{Method: http.MethodGet, URL: u})
+}
+
+type snapshotResponseWriter struct {
+	header     http.Header
+	statusCode int
+	content    bytes.Buffer
+}
+
+func (sw *snapshotResponseWriter) Header() http.Header {
+	return sw.header
+}
+
+func (sw *snapshotResponseWriter) WriteHeader(statusCode int) {
+	sw.statusCode = statusCode
+}
+
+func (sw *snapshotResponseWriter) Write(data []byte) (int, error) {
+	return sw.content.Write(data)
+}
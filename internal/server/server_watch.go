@@ -0,0 +1,151 @@
+package server
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const watchingInterval = time.Second
+
+type sourceFileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchSourceChanges polls the directories of the working directory packages.
+// When some source files are changed, the packages are re-analyzed in background.
+// Polling is used to avoid depending on platform specific file notification APIs.
+//
+// Only one analysis runs at any time. If files are changed during an analysis,
+// another analysis will be started after the current one finishes.
+func (ds *docServer) watchSourceChanges(args []string, options PageOutputOptions) {
+	dirs := ds.watchedDirectories()
+	last := snapshotSourceFiles(dirs, options.IncludeTests)
+	for {
+		time.Sleep(watchingInterval)
+
+		current := snapshotSourceFiles(dirs, options.IncludeTests)
+		if sameSourceFileStates(last, current) {
+			continue
+		}
+
+		// Wait until the files become stable, for editors might
+		// save several files one by one.
+		for {
+			time.Sleep(watchingInterval)
+			next := snapshotSourceFiles(dirs, options.IncludeTests)
+			if sameSourceFileStates(current, next) {
+				break
+			}
+			current = next
+		}
+		last = current
+
+		ds.analyzingLogger.Println(ds.currentTranslationSafely().Text_Server_SourceChanged())
+
+		ds.mutex.Lock()
+		ds.analyzingLogs = ds.analyzingLogs[:0]
+		ds.mutex.Unlock()
+
+		if !ds.analyzePackages(args, options) {
+			// Keep the old result. Try again when files are changed later.
+			continue
+		}
+
+		// New packages might be added and old ones might be removed.
+		if newDirs := ds.watchedDirectories(); !sameStrings(dirs, newDirs) {
+			dirs = newDirs
+			last = snapshotSourceFiles(dirs, options.IncludeTests)
+		}
+	}
+}
+
+// The working directory is always watched, so that go.mod changes
+// and new packages directly in it can be detected.
+func (ds *docServer) watchedDirectories() []string {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	var dirs = map[string]bool{ds.workingDirectory: true}
+	for i, n := 0, ds.analyzer.NumPackages(); i < n; i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		if ds.inWorkingDirectory(pkg.Directory) {
+			dirs[pkg.Directory] = true
+		}
+	}
+
+	var list = make([]string, 0, len(dirs))
+	for dir := range dirs {
+		list = append(list, dir)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// inWorkingDirectory reports whether or not dir is the working directory
+// or one of its sub-directories.
+func (ds *docServer) inWorkingDirectory(dir string) bool {
+	if dir == "" || !strings.HasPrefix(dir, ds.workingDirectory) {
+		return false
+	}
+	return len(dir) == len(ds.workingDirectory) ||
+		dir[len(ds.workingDirectory)] == filepath.Separator ||
+		strings.HasSuffix(ds.workingDirectory, string(filepath.Separator)) // root
+}
+
+func snapshotSourceFiles(dirs []string, includeTests bool) map[string]sourceFileState {
+	var states = make(map[string]sourceFileState, len(dirs)*8)
+	for _, dir := range dirs {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			// The directory might be removed.
+			continue
+		}
+		for _, info := range infos {
+			if info.IsDir() {
+				continue
+			}
+			name := info.Name()
+			switch {
+			case name == "go.mod", name == "go.sum":
+			case !includeTests && strings.HasSuffix(name, "_test.go"):
+				continue
+			case strings.HasSuffix(name, ".go"):
+			default:
+				continue
+			}
+			states[filepath.Join(dir, name)] = sourceFileState{
+				modTime: info.ModTime(),
+				size:    info.Size(),
+			}
+		}
+	}
+	return states
+}
+
+func sameSourceFileStates(a, b map[string]sourceFileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, sa := range a {
+		if sb, ok := b[k]; !ok || sb.size != sa.size || !sb.modTime.Equal(sa.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package theme
+
+import (
+	"regexp"
+)
+
+// Custom is a theme whose CSS is loaded from a user file.
+type Custom struct {
+	name string
+	css  string
+}
+
+func NewCustom(name, css string) *Custom {
+	return &Custom{name: name, css: css}
+}
+
+func (t *Custom) Name() string { return t.name }
+
+// Custom themes can't specify chart colors now.
+func (*Custom) ChartColors() (background, foreground string) {
+	return "#fff", "#000"
+}
+
+func (t *Custom) CSS() string { return t.css }
+
+// The CSS classes and ids which the pages rely on.
+// A theme should define styles for all of them.
+var RequiredSelectors = []string{
+	// Content folding in package details pages.
+	// The "fold-items" and "fold-docs" spans must be hidden
+	// by default and shown when the previous checkbox is checked.
+	"input.fold", ".fold-items", ".fold-docs", ".nodocs",
+
+	// Deprecated declarations in package details pages.
+	".deprecated",
+
+	// Titles, the ":" suffixes are added by CSS.
+	".title",
+
+	// Elements which are hidden by default.
+	".hidden",
+
+	// Anchor targets.
+	".anchor", ".codeline",
+
+	// Line numbers in source code pages are added by CSS.
+	".line-numbers",
+
+	// Source code highlighting (written by astVisitor).
+	".ident", ".keyword", ".comment", ".lit-number", ".lit-string",
+
+	// The popup of identifier tooltips in source code pages (created by JavaScript).
+	"#ident-tip",
+
+	// Others.
+	".path-duplicate", ".b", ".golds-update",
+	"#header", "#footer", "#theme-switcher",
+}
+
+// MissingSelectors returns the required selectors not used in css.
+func MissingSelectors(css string) []string {
+	var missings []string
+	for _, sel := range RequiredSelectors {
+		// The selector must not be followed by a name char,
+		// so that ".fold" doesn't match ".fold-items".
+		re := regexp.MustCompile(regexp.QuoteMeta(sel) + `($|[^\w-])`)
+		if !re.MatchString(css) {
+			missings = append(missings, sel)
+		}
+	}
+	return missings
+}
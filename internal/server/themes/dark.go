@@ -0,0 +1,105 @@
+package theme
+
+type Dark struct{}
+
+func (*Dark) Name() string { return "dark" }
+
+// The background and foreground colors of SVG charts.
+func (*Dark) ChartColors() (background, foreground string) {
+	return "#1e1e1e", "#ddd"
+}
+
+func (*Dark) CSS() string {
+	return `
+body {color: #ccc; background: #1e1e1e; font-family: {{ .Fonts }};}
+.grey {color: #666;}
+a {color: #6bd;}
+a.path-duplicate {color: #468;}
+.module-version {color: #999; font-style: italic; font-size: smaller; text-decoration: none;}
+ol.package-list {line-height: 139%;}
+h3 {background: #333;}
+
+.b {font-weight: bold;}
+
+/* content folding */
+span.nodocs {padding-left: 1px; padding-right: 1px;}
+span.nodocs:before {content: ". ";}
+span.deprecated {text-decoration: line-through;}
+label {cursor: pointer; padding-left: 1px; padding-right: 1px;}
+input.fold {display: none;}
+input + label + .fold-items {display: none;}
+input + label + .fold-docs {display: none;}
+input:checked + label + .fold-items {display: inline;}
+input:checked + label + .fold-docs {display: inline;}
+input + label:before {content: "+ ";}
+input:checked + label:before {content: "- ";}
+input:checked + label.fold-items:after {content: "{{ .Colon }}";}
+
+.title:after {content: "{{ .Colon }}";}
+
+/* code page */
+pre.line-numbers {
+	counter-reset: line;
+}
+pre.line-numbers span.codeline {
+	counter-increment: line;
+	margin-left: 44pt;
+	tab-size: 7;
+	-webkit-tab-size: 7;
+	-moz-tab-size: 7;
+	-ms-tab-size: 7;
+}
+pre.line-numbers span.codeline:before {
+	display: inline-block;
+	text-align:right;
+	position: absolute;
+	width: 40pt;
+	left: 8pt;
+	padding: 0 3pt 0 0;
+	border-right: 0;
+	content: counter(line)"|";
+	user-select: none;
+	-webkit-user-select: none;
+	-moz-user-select: none;
+	-ms-user-select: none;
+}
+
+hr {color: #666;}
+
+.anchor {}
+.codeline {}
+
+.codeline:target, .anchor:target {border-top: 1px solid #554; border-bottom: 1px solid #554; background-color: #332;}
+
+code .ident {color: #8ac;}
+code .id-type {color: #8ac;}
+code .id-value {color: #8ac;}
+code .id-function {color: #8ac;}
+code .lit-number {color: #f99;}
+code .lit-string {color: #d99;}
+code .keyword {color: #d97;}
+code .comment {color: #7a7; font-style: italic;}
+
+#header {
+	padding-bottom: 8px;
+	border-bottom: 1px solid #666;
+}
+
+#footer {
+	padding: 5px 8px;
+	font-size: small;
+	color: #999;
+	border-top: 1px solid #666;
+}
+
+.golds-update {text-align: center; font-size: smaller; background: #333; padding: 3px;}
+.hidden {display: none;}
+#theme-switcher {float: right; font-size: smaller;}
+#ident-tip {
+	position: absolute; z-index: 10; max-width: 60em; padding: 3px 6px;
+	border: 1px solid #666; background: #333; color: #ccc; font-size: smaller; white-space: pre-wrap;
+}
+input[type=text] {background: #2a2a2a; color: #ccc; border: 1px solid #555;}
+
+`
+}
@@ -4,6 +4,11 @@ type Light struct{}
 
 func (*Light) Name() string { return "light" }
 
+// The background and foreground colors of SVG charts.
+func (*Light) ChartColors() (background, foreground string) {
+	return "#fff", "#000"
+}
+
 func (*Light) CSS() string {
 	return `
 body {color: #333; font-family: {{ .Fonts }};}
@@ -19,6 +24,7 @@ h3 {background: #ddd;}
 /* content folding */
 span.nodocs {padding-left: 1px; padding-right: 1px;}
 span.nodocs:before {content: ". ";}
+span.deprecated {text-decoration: line-through;}
 label {cursor: pointer; padding-left: 1px; padding-right: 1px;}
 input.fold {display: none;}
 input + label + .fold-items {display: none;}
@@ -88,6 +94,11 @@ code .comment {color: green; font-style: italic;}
 
 .golds-update {text-align: center; font-size: smaller; background: #eee; padding: 3px;}
 .hidden {display: none;}
+#theme-switcher {float: right; font-size: smaller;}
+#ident-tip {
+	position: absolute; z-index: 10; max-width: 60em; padding: 3px 6px;
+	border: 1px solid #888; background: #ffd; color: #333; font-size: smaller; white-space: pre-wrap;
+}
 
 `
 }
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
+)
+
+// DiffAPI compares the exported APIs of the packages (specified by args)
+// in two snapshots of the current directory and writes the changes into w.
+// A snapshot is either a directory or a git revision.
+func DiffAPI(options PageOutputOptions, oldSnapshot, newSnapshot string, args []string, w io.Writer, asJSON bool, printUsage func(io.Writer)) {
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatalln("Getwd error:", err)
+	}
+
+	snapshotAPI := func(snapshot string) code.APISnapshot {
+		dir, cleanup := snapshotDirectory(wd, snapshot)
+
+		// The temporary directory must be removed before exiting.
+		if err := os.Chdir(dir); err != nil {
+			cleanup()
+			log.Fatalln("Chdir error:", err)
+		}
+		ds := &docServer{
+			phase:          Phase_Unprepared,
+			analyzer:       &code.CodeAnalyzer{},
+			noExitOnErrors: true,
+		}
+		ds.initSettings(options.PreferredLang)
+		ok := ds.tryAnalyzing(args, options)
+		os.Chdir(wd)
+		cleanup()
+		if !ok {
+			if printUsage != nil {
+				printUsage(os.Stdout)
+			}
+			os.Exit(1)
+		}
+
+		var pkgs []*code.Package
+		for i, n := 0, ds.analyzer.NumPackages(); i < n; i++ {
+			pkg := ds.analyzer.PackageAt(i)
+			if ds.inWorkingDirectory(pkg.Directory) {
+				pkgs = append(pkgs, pkg)
+			}
+		}
+		return ds.analyzer.APISnapshot(pkgs)
+	}
+
+	changes := code.DiffAPI(snapshotAPI(oldSnapshot), snapshotAPI(newSnapshot))
+
+	if asJSON {
+		data, err := json.MarshalIndent(changes, "", "\t")
+		if err != nil {
+			log.Fatalln("Marshal error:", err)
+		}
+		w.Write(data)
+		fmt.Fprintln(w)
+		return
+	}
+
+	var lastPkgPath string
+	for _, c := range changes {
+		if c.Package != lastPkgPath {
+			lastPkgPath = c.Package
+			fmt.Fprintln(w, c.Package)
+		}
+		switch c.Kind {
+		case "added":
+			fmt.Fprintf(w, "\t+ %s\n", strings.TrimSpace(c.Declaration+" "+c.New))
+		case "removed":
+			fmt.Fprintf(w, "\t- %s\n", strings.TrimSpace(c.Declaration+" "+c.Old))
+		case "changed":
+			fmt.Fprintf(w, "\t~ %s\n\t\t- %s\n\t\t+ %s\n", c.Declaration, c.Old, c.New)
+		}
+	}
+}
+
+// snapshotDirectory returns the directory of a snapshot of the current
+// directory. If the snapshot is not a directory, it is viewed as a git
+// revision and the files at that revision are extracted into a temporary
+// directory, which will be removed by calling cleanup.
+func snapshotDirectory(wd, snapshot string) (dir string, cleanup func()) {
+	if info, err := os.Stat(snapshot); err == nil && info.IsDir() {
+		dir, err := filepath.Abs(snapshot)
+		if err != nil {
+			log.Fatalln("Abs error:", err)
+		}
+		return dir, func() {}
+	}
+
+	output, err := util.RunShellCommand(time.Minute, wd, nil, "git", "rev-parse", "--show-toplevel", "--show-prefix", snapshot+"^{commit}")
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if err != nil || len(lines) < 2 {
+		log.Fatalf("%s is neither a directory nor a git revision: %s", snapshot, output)
+	}
+	toplevel, prefix := lines[0], ""
+	if len(lines) > 2 {
+		prefix = lines[1]
+	}
+
+	// The whole repository is extracted, for the current
+	// directory might depend on the other parts of it.
+	output, err = util.RunShellCommand(time.Minute*5, toplevel, nil, "git", "archive", "--format=tar", snapshot)
+	if err != nil {
+		log.Fatalf("git archive %s error: %s", snapshot, output)
+	}
+
+	root, err := ioutil.TempDir("", "golds-diff-")
+	if err != nil {
+		log.Fatalln("TempDir error:", err)
+	}
+	cleanup = func() { os.RemoveAll(root) }
+
+	tr := tar.NewReader(bytes.NewReader(output))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			cleanup()
+			log.Fatalf("read git archive of %s error: %s", snapshot, err)
+		}
+		path := filepath.Join(root, filepath.FromSlash(hdr.Name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, 0700)
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+				var data []byte
+				if data, err = ioutil.ReadAll(tr); err == nil {
+					err = ioutil.WriteFile(path, data, 0644)
+				}
+			}
+		case tar.TypeSymlink:
+			// The links are kept as they are. Links pointing to
+			// outside of the repository might be broken.
+			if err = os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+				err = os.Symlink(hdr.Linkname, path)
+			}
+		default:
+			// git archive only outputs directories, regular files,
+			// symlinks and the global header holding the commit id.
+			if hdr.Typeflag != tar.TypeXGlobalHeader {
+				log.Printf("%s in git archive of %s is skipped (type %c)", hdr.Name, snapshot, hdr.Typeflag)
+			}
+		}
+		if err != nil {
+			cleanup()
+			log.Fatalf("extract git archive of %s error: %s", snapshot, err)
+		}
+	}
+
+	return filepath.Join(root, filepath.FromSlash(prefix)), cleanup
+}
@@ -239,6 +239,9 @@ Generate:
 	if !buildIdUsesPages && linkedPageInfo.resType == ResTypeReference {
 		panic("identifer-uses page (" + linkedPageInfo.resPath + ") should not be build")
 	}
+	if !buildIdUsesPages && linkedPageInfo.resType == ResTypeCall {
+		panic("function-calls page (" + linkedPageInfo.resPath + ") should not be build")
+	}
 	if !enableSoruceNavigation && linkedPageInfo.resType == ResTypeImplementation {
 		panic("method-implementation page (" + linkedPageInfo.resPath + ") should not be build")
 	}
@@ -325,8 +328,10 @@ func GenDocs(options PageOutputOptions, args []string, outputDir string, silentM
 		phase:    Phase_Unprepared,
 		analyzer: &code.CodeAnalyzer{},
 	}
-	ds.initSettings(options.PreferredLang)
-	ds.analyze(args, printUsage)
+	customThemes := loadCustomThemes(options)
+	ds.initSettings(options.PreferredLang, customThemes...)
+	ds.changeSettings(initialThemeName(options, customThemes))
+	ds.analyze(args, options, printUsage)
 
 	// ...
 	outputDir = filepath.Join(outputDir, "generated-"+time.Now().Format("20060102150405"))
@@ -443,4 +448,6 @@ func GenDocs(options PageOutputOptions, args []string, outputDir string, silentM
 	log.Printf("Docs are generated in %s.", outputDir)
 	log.Println("Run the following command to view the docs:")
 	log.Printf("\t%s", viewDocsCommand(outputDir))
+
+	ds.exitOnImportViolations()
 }
@@ -0,0 +1,424 @@
+package server
+
+import (
+	"encoding/json"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"go101.org/golds/code"
+)
+
+// JsonFormatVersion is written into every generated JSON file.
+// It must be increased when the output format changes incompatibly,
+// so that the tools consuming the files may detect the changes.
+const JsonFormatVersion = 1
+
+type JsonData_Index struct {
+	FormatVersion int
+	GoldsVersion  string
+	Packages      []JsonData_PackageEntry
+}
+
+type JsonData_PackageEntry struct {
+	ImportPath string
+	Name       string
+	File       string // relative to the index file
+}
+
+type JsonData_Package struct {
+	FormatVersion int
+	ImportPath    string
+	Name          string
+	IsStandard    bool
+	Module        *JsonData_Module `json:",omitempty"`
+	Files         []string
+	Deps          []string
+	DepedBys      []string
+
+	Types  []JsonData_Type
+	Consts []JsonData_Value
+	Vars   []JsonData_Value
+	Funcs  []JsonData_Value
+}
+
+type JsonData_Module struct {
+	Path    string
+	Version string `json:",omitempty"`
+}
+
+type JsonData_Position struct {
+	File   string // package path + "/" + bare filename
+	Line   int
+	Column int
+}
+
+type JsonData_Type struct {
+	Name          string
+	IsAlias       bool
+	Kind          string
+	Denoting      string
+	Position      JsonData_Position
+	Doc           string `json:",omitempty"`
+	Fields        []JsonData_Selector
+	Methods       []JsonData_Selector
+	Implements    []JsonData_TypeRef
+	ImplementedBy []JsonData_TypeRef
+	Values        []JsonData_ValueRef
+	AsParamsOf    []JsonData_ValueRef
+	AsResultsOf   []JsonData_ValueRef
+	References    []JsonData_Position
+}
+
+type JsonData_Selector struct {
+	Name     string
+	Type     string
+	Promoted bool
+	Position JsonData_Position
+	Doc      string `json:",omitempty"`
+
+	// Only direct (non-promoted) selectors have references listed.
+	References []JsonData_Position `json:",omitempty"`
+}
+
+type JsonData_TypeRef struct {
+	Package   string
+	Name      string
+	IsPointer bool
+}
+
+type JsonData_ValueRef struct {
+	Package  string
+	Name     string
+	Receiver string `json:",omitempty"` // for methods only
+}
+
+type JsonData_Value struct {
+	Name       string
+	Type       string
+	Position   JsonData_Position
+	Doc        string `json:",omitempty"`
+	References []JsonData_Position
+}
+
+func buildJsonData_Position(pos token.Position, pkg *code.Package) JsonData_Position {
+	if pos.Filename == "" {
+		return JsonData_Position{}
+	}
+	return JsonData_Position{
+		File:   pkg.Path() + "/" + filepath.Base(pos.Filename),
+		Line:   pos.Line,
+		Column: pos.Column,
+	}
+}
+
+func buildJsonData_References(analyzer *code.CodeAnalyzer, obj types.Object) []JsonData_Position {
+	if obj == nil {
+		return nil
+	}
+	ids := analyzer.ObjectReferences(obj)
+	refs := make([]JsonData_Position, 0, len(ids))
+	for _, id := range ids {
+		pkg := id.FileInfo.Pkg
+		pos := pkg.PPkg.Fset.PositionFor(id.AstIdent.NamePos, false)
+		refs = append(refs, JsonData_Position{
+			File:   pkg.Path() + "/" + id.FileInfo.AstBareFileName(),
+			Line:   pos.Line,
+			Column: pos.Column,
+		})
+	}
+	return refs
+}
+
+// Package paths are used as type qualifiers in the generated type strings.
+func jsonTypeString(tt types.Type) string {
+	return types.TypeString(tt, func(p *types.Package) string {
+		return p.Path()
+	})
+}
+
+func buildJsonData_TypeRefs(list []TypeForListing) []JsonData_TypeRef {
+	refs := make([]JsonData_TypeRef, 0, len(list))
+	for _, t := range list {
+		refs = append(refs, JsonData_TypeRef{
+			Package:   t.Package().Path(),
+			Name:      t.Name(),
+			IsPointer: t.IsPointer,
+		})
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Package != refs[j].Package {
+			return refs[i].Package < refs[j].Package
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
+
+func buildJsonData_ValueRefs(list []ValueForListing) []JsonData_ValueRef {
+	refs := make([]JsonData_ValueRef, 0, len(list))
+	for _, v := range list {
+		ref := JsonData_ValueRef{
+			Package: v.Package().Path(),
+			Name:    v.Name(),
+		}
+		if f, ok := v.ValueResource.(code.FunctionResource); ok && f.IsMethod() {
+			if _, tn, isStar := f.ReceiverTypeName(); tn != nil {
+				if isStar {
+					ref.Receiver = "*" + tn.Name()
+				} else {
+					ref.Receiver = tn.Name()
+				}
+			}
+		}
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Package != refs[j].Package {
+			return refs[i].Package < refs[j].Package
+		}
+		if refs[i].Receiver != refs[j].Receiver {
+			return refs[i].Receiver < refs[j].Receiver
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
+
+func buildJsonData_Selectors(analyzer *code.CodeAnalyzer, selectors []*code.Selector, pkg *code.Package) []JsonData_Selector {
+	sels := make([]JsonData_Selector, 0, len(selectors))
+	for _, sel := range selectors {
+		var tt types.Type
+		if sel.Field != nil {
+			tt = sel.Field.Type.TT
+		} else {
+			tt = sel.Method.Type.TT
+		}
+		s := JsonData_Selector{
+			Name:     sel.Name(),
+			Type:     jsonTypeString(tt),
+			Promoted: sel.Depth > 0,
+		}
+		if selPkg := sel.Package(); selPkg != nil {
+			s.Position = buildJsonData_Position(sel.Position(), selPkg)
+		}
+		if sel.Field != nil {
+			s.Doc = sel.Field.Documentation()
+		} else {
+			s.Doc = sel.Method.Documentation()
+		}
+		if !s.Promoted {
+			s.References = buildJsonData_References(analyzer, sel.Object())
+		}
+		sels = append(sels, s)
+	}
+	return sels
+}
+
+func buildJsonData_Package(analyzer *code.CodeAnalyzer, details *PackageDetails) *JsonData_Package {
+	pkg := details.Package
+
+	files := make([]string, 0, len(details.Files))
+	for _, f := range details.Files {
+		files = append(files, f.Filename)
+	}
+	deps := make([]string, 0, len(pkg.Deps))
+	for _, dep := range pkg.Deps {
+		deps = append(deps, dep.Path())
+	}
+	depedBys := make([]string, 0, len(pkg.DepedBys))
+	for _, dep := range pkg.DepedBys {
+		depedBys = append(depedBys, dep.Path())
+	}
+
+	var mod *JsonData_Module
+	if pkg.Mod != nil {
+		mod = &JsonData_Module{Path: pkg.Mod.Root, Version: pkg.Mod.Version}
+	}
+
+	ts := make([]JsonData_Type, 0, len(details.ExportedTypeNames))
+	for _, et := range details.ExportedTypeNames {
+		tn := et.TypeName
+		denoting := tn.Denoting()
+
+		var kind string
+		switch denoting.TT.Underlying().(type) {
+		case *types.Basic:
+			kind = "basic"
+		case *types.Pointer:
+			kind = "pointer"
+		case *types.Struct:
+			kind = "struct"
+		case *types.Array:
+			kind = "array"
+		case *types.Slice:
+			kind = "slice"
+		case *types.Map:
+			kind = "map"
+		case *types.Chan:
+			kind = "chan"
+		case *types.Signature:
+			kind = "func"
+		case *types.Interface:
+			kind = "interface"
+		}
+
+		ts = append(ts, JsonData_Type{
+			Name:          tn.Name(),
+			IsAlias:       tn.Alias != nil,
+			Kind:          kind,
+			Denoting:      jsonTypeString(denoting.TT),
+			Position:      buildJsonData_Position(tn.Position(), pkg),
+			Doc:           tn.Documentation(),
+			Fields:        buildJsonData_Selectors(analyzer, et.Fields, pkg),
+			Methods:       buildJsonData_Selectors(analyzer, et.Methods, pkg),
+			Implements:    buildJsonData_TypeRefs(et.Implements),
+			ImplementedBy: buildJsonData_TypeRefs(et.ImplementedBys),
+			Values:        buildJsonData_ValueRefs(et.Values),
+			AsParamsOf:    buildJsonData_ValueRefs(et.AsInputsOf),
+			AsResultsOf:   buildJsonData_ValueRefs(et.AsOutputsOf),
+			References:    buildJsonData_References(analyzer, tn.TypeName),
+		})
+	}
+
+	consts := make([]JsonData_Value, 0, len(details.ValueResources))
+	vars := make([]JsonData_Value, 0, len(details.ValueResources))
+	funcs := make([]JsonData_Value, 0, len(details.ValueResources))
+	for _, v := range details.ValueResources {
+		var obj types.Object
+		value := JsonData_Value{
+			Name:     v.Name(),
+			Type:     jsonTypeString(v.TType()),
+			Position: buildJsonData_Position(v.Position(), pkg),
+			Doc:      v.Documentation(),
+		}
+		switch v := v.(type) {
+		case *code.Constant:
+			obj = v.Const
+			value.References = buildJsonData_References(analyzer, obj)
+			consts = append(consts, value)
+		case *code.Variable:
+			obj = v.Var
+			value.References = buildJsonData_References(analyzer, obj)
+			vars = append(vars, value)
+		case *code.Function:
+			if v.Func != nil {
+				obj = v.Func
+			} else { // v.Builtin != nil
+				obj = v.Builtin
+			}
+			value.References = buildJsonData_References(analyzer, obj)
+			funcs = append(funcs, value)
+		}
+	}
+
+	return &JsonData_Package{
+		FormatVersion: JsonFormatVersion,
+		ImportPath:    details.ImportPath,
+		Name:          details.Name,
+		IsStandard:    details.IsStandard,
+		Module:        mod,
+		Files:         files,
+		Deps:          deps,
+		DepedBys:      depedBys,
+		Types:         ts,
+		Consts:        consts,
+		Vars:          vars,
+		Funcs:         funcs,
+	}
+}
+
+// GenJSON dumps the analysis results as JSON files, one for each package,
+// plus an index file listing all the packages.
+func GenJSON(options PageOutputOptions, args []string, outputDir string, silentMode bool, printUsage func(io.Writer), increaseGCFrequency bool) {
+	forTesting := outputDir == ""
+	silent := silentMode || forTesting
+	if increaseGCFrequency {
+		debug.SetGCPercent(75)
+	}
+
+	ds := &docServer{
+		phase:    Phase_Unprepared,
+		analyzer: &code.CodeAnalyzer{},
+	}
+	ds.initSettings(options.PreferredLang)
+	ds.analyze(args, options, printUsage)
+
+	// ...
+	outputDir = filepath.Join(outputDir, "generated-json-"+time.Now().Format("20060102150405"))
+
+	writeJsonFile := func(path string, v interface{}) int {
+		data, err := json.MarshalIndent(v, "", "\t")
+		if err != nil {
+			log.Fatalln("Marshal error:", err)
+		}
+
+		if forTesting {
+			return len(data)
+		}
+
+		path = filepath.Join(outputDir, path)
+		path = strings.Replace(path, "/", string(filepath.Separator), -1)
+		path = strings.Replace(path, "\\", string(filepath.Separator), -1)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			log.Fatalln("Mkdir error:", err)
+		}
+
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			log.Fatalln("Write file error:", err)
+		}
+
+		return len(data)
+	}
+
+	numPkgs := ds.analyzer.NumPackages()
+	index := JsonData_Index{
+		FormatVersion: JsonFormatVersion,
+		GoldsVersion:  options.GoldsVersion,
+		Packages:      make([]JsonData_PackageEntry, 0, numPkgs),
+	}
+
+	numFiles, numBytes := 0, 0
+	for i := 0; i < numPkgs; i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		details := buildPackageDetailsData(ds.analyzer, pkg.Path(), packagePageOptions{sortBy: "alphabet", filter: "exporteds"})
+		if details == nil {
+			continue
+		}
+
+		file := string(ResTypePackage) + "/" + pkg.Path() + ".json"
+		numBytes += writeJsonFile(file, buildJsonData_Package(ds.analyzer, details))
+		numFiles++
+
+		index.Packages = append(index.Packages, JsonData_PackageEntry{
+			ImportPath: details.ImportPath,
+			Name:       details.Name,
+			File:       file,
+		})
+
+		if !silent {
+			log.Printf("Generated %s.", file)
+		}
+	}
+
+	numBytes += writeJsonFile("index.json", &index)
+	numFiles++
+
+	if forTesting {
+		return
+	}
+
+	log.Printf("Done (%d files are generated and %d bytes are written).", numFiles, numBytes)
+	log.Printf("JSON files are generated in %s.", outputDir)
+
+	ds.exitOnImportViolations()
+}
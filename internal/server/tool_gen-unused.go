@@ -0,0 +1,31 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"go101.org/golds/code"
+)
+
+// GenUnusedReport writes the exported identifiers (in the packages under
+// the working directory) which are not used by other packages into w.
+func GenUnusedReport(options PageOutputOptions, args []string, w io.Writer, printUsage func(io.Writer)) {
+	ds := &docServer{
+		phase:    Phase_Unprepared,
+		analyzer: &code.CodeAnalyzer{},
+	}
+	ds.initSettings(options.PreferredLang)
+	ds.analyze(args, options, printUsage)
+
+	for _, u := range ds.buildUnusedIdentifiersData() {
+		fmt.Fprintf(w, "%s (%d)\n", u.Package.Path(), len(u.Resources))
+		for _, res := range u.Resources {
+			pos := res.Position()
+			if rel, err := filepath.Rel(ds.workingDirectory, pos.Filename); err == nil {
+				pos.Filename = rel
+			}
+			fmt.Fprintf(w, "\t%s %s\t%s:%d\n", resourceKind(res), res.Name(), pos.Filename, pos.Line)
+		}
+	}
+}
@@ -37,6 +37,10 @@ func (*Chinese) Text_BlankList() string {
 	return "（无）"
 }
 
+func (*Chinese) Text_Theme() string { return "主题" }
+
+func (*Chinese) Text_Language() string { return "语言" }
+
 ///////////////////////////////////////////////////////////////////
 // server
 ///////////////////////////////////////////////////////////////////
@@ -45,6 +49,10 @@ func (*Chinese) Text_Server_Started() string {
 	return "服务已启动："
 }
 
+func (*Chinese) Text_Server_SourceChanged() string {
+	return "检测到源代码变化。重新分析中......"
+}
+
 ///////////////////////////////////////////////////////////////////
 // analyzing
 ///////////////////////////////////////////////////////////////////
@@ -119,6 +127,10 @@ func (*Chinese) Text_Analyzing_CacheSourceFiles(d time.Duration) string {
 	return fmt.Sprintf("缓存源文件：%s", d)
 }
 
+func (*Chinese) Text_Analyzing_CollectExamples(d time.Duration) string {
+	return fmt.Sprintf("搜集代码示例：%s", d)
+}
+
 func (*Chinese) Text_Analyzing_Done(d time.Duration, memoryUse string) string {
 	return fmt.Sprintf("分析完毕（共用时%s，最终消耗内存%s）", d, memoryUse)
 }
@@ -193,8 +205,12 @@ func (*Chinese) Text_SortByItem(by string) string {
 		return "按字母排序"
 	case "popularity":
 		return "按流行度排序"
+	case "usage":
+		return "按使用量排序"
 	case "importedbys":
 		return "按被引入量排序"
+	case "modules":
+		return "按模块分组"
 	default:
 		panic("unknown sort-by: " + by)
 	}
@@ -213,6 +229,30 @@ func (*Chinese) Text_FilterItem(fltr string) string {
 	}
 }
 
+///////////////////////////////////////////////////////////////////
+// search page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_Search() string { return "搜索" }
+
+///////////////////////////////////////////////////////////////////
+// module page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_Module(modulePath string) string {
+	return fmt.Sprintf("模块：%s", modulePath)
+}
+
+func (*Chinese) Text_ModulePath() string { return "模块路径" }
+
+func (*Chinese) Text_Version() string { return "版本" }
+
+func (*Chinese) Text_ReplacedBy() string { return "被替换为" }
+
+func (*Chinese) Text_Requires() string { return "需要这些模块" }
+
+func (*Chinese) Text_RequiredBy() string { return "被这些模块所需要" }
+
 ///////////////////////////////////////////////////////////////////
 // package details page: type details
 ///////////////////////////////////////////////////////////////////
@@ -313,6 +353,34 @@ func (*Chinese) Text_References(num int) string {
 	return fmt.Sprintf("引用（%d+）", num)
 }
 
+func (*Chinese) Text_Examples(num int) string {
+	return fmt.Sprintf("代码示例（%d）", num)
+}
+
+func (*Chinese) Text_TypeSet() string {
+	return "类型集"
+}
+
+func (*Chinese) Text_InheritedFrom() string {
+	return "继承自"
+}
+
+func (*Chinese) Text_Deprecated() string {
+	return "已弃用"
+}
+
+func (*Chinese) Text_OnlyOnPlatforms(platforms string) string {
+	return "（仅存在于" + platforms + "）"
+}
+
+func (*Chinese) Text_DeclarationsOnOtherPlatforms(num int) string {
+	return fmt.Sprintf("仅存在于其它平台上（%d）", num)
+}
+
+func (*Chinese) Text_UsedByPackages(numPkgs, numUses int) string {
+	return fmt.Sprintf("（被%d个代码包使用%d次）", numPkgs, numUses)
+}
+
 ///////////////////////////////////////////////////////////////////
 // package dependencies page
 ///////////////////////////////////////////////////////////////////
@@ -329,6 +397,40 @@ func (*Chinese) Text_Imports() string { return "引入了这些代码包" }
 
 func (*Chinese) Text_ImportedBy() string { return "被这些代码包引入" }
 
+func (*Chinese) Text_UsedExportedIdentifiers() string { return "被引入包使用的导出标识符" }
+
+func (*Chinese) Text_ImportViolations() string { return "违反引入规则的引入" }
+
+func (*Chinese) Text_ImportViolationsWithDetailsLink(count int, detailsLink string) string {
+	if count == 0 {
+		return "违反引入规则的引入（无）"
+	}
+	return fmt.Sprintf(`违反引入规则的引入（<a href="%s">%d</a>）`, detailsLink, count)
+}
+
+func (*Chinese) Text_ForbiddenByImportRule(line int, rule string) string {
+	return fmt.Sprintf("（被第%d行的规则禁止：%s）", line, rule)
+}
+
+func (*Chinese) Text_DependencyGraph() string { return "依赖关系图" }
+
+func (*Chinese) Text_DependencyGraphOption(option string) string {
+	switch option {
+	case "depth":
+		return "深度："
+	case "unlimited":
+		return "不限"
+	case "std":
+		return "标准库包："
+	case "show":
+		return "显示"
+	case "hide":
+		return "隐藏"
+	default:
+		panic("unknown dependency graph option: " + option)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // method implementation page
 ///////////////////////////////////////////////////////////////////
@@ -344,6 +446,37 @@ func (*Chinese) Text_NumMethodsImplementingNothing(count int) string {
 	return fmt.Sprintf("（%d个其它方法什么也没实现）", count)
 }
 
+///////////////////////////////////////////////////////////////////
+// type hierarchy page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_TypeHierarchy() string { return "类型层次" }
+
+func (*Chinese) Text_EmbeddingTree(forInterface bool) string {
+	if forInterface {
+		return "内嵌接口"
+	}
+	return "内嵌字段"
+}
+
+///////////////////////////////////////////////////////////////////
+// function calls page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_FunctionCalls() string { return "函数调用" }
+
+func (*Chinese) Text_Callers(num int) string {
+	return fmt.Sprintf("%d个调用者", num)
+}
+
+func (*Chinese) Text_Callees(num int) string {
+	return fmt.Sprintf("调用了%d个函数", num)
+}
+
+func (*Chinese) Text_ThroughInterfaceMethod(method string) string {
+	return fmt.Sprintf("（通过%s）", method)
+}
+
 ///////////////////////////////////////////////////////////////////
 // object references(uses) page
 ///////////////////////////////////////////////////////////////////
@@ -367,6 +500,13 @@ func (*Chinese) Text_ObjectUses(num int) string {
 	return fmt.Sprintf("%d处使用", num)
 }
 
+func (*Chinese) Text_DeprecatedUses(num int) string {
+	if num == 0 {
+		return "已弃用，未被其它代码包使用"
+	}
+	return fmt.Sprintf("已弃用，被其它代码包使用%d次", num)
+}
+
 ///////////////////////////////////////////////////////////////////
 // source code page
 ///////////////////////////////////////////////////////////////////
@@ -379,6 +519,14 @@ func (*Chinese) Text_SourceFilePath() string { return "源文件：" }
 
 func (*Chinese) Text_GeneratedFrom() string { return "从此文件生成" }
 
+func (*Chinese) Text_InterfaceMethodCallImplementations() string {
+	return "接口方法调用的实现"
+}
+
+func (*Chinese) Text_SourceLine(line int) string {
+	return fmt.Sprintf("第%d行", line)
+}
+
 ///////////////////////////////////////////////////////////////////
 // statistics
 ///////////////////////////////////////////////////////////////////
@@ -566,13 +714,51 @@ func (*Chinese) Text_Othertatistics(values map[string]interface{}) string {
 	输出标识符的平均长度为%.2f。
 
 	<img src="%s"></image>
+
+	当前工作目录下的代码包中未被其它代码包使用的输出标识符列在<a href="%s">这里</a>。
 `,
 		values["averageIdentiferLength"],
 
 		values["exportedidentifiersByLengthsChartURL"],
+
+		values["unusedExportedIdentifiersURL"],
 	)
 }
 
+///////////////////////////////////////////////////////////////////
+// unused identifiers page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_UnusedExportedIdentifiers() string {
+	return "未被使用的输出标识符"
+}
+
+func (*Chinese) Text_UnusedExportedIdentifiersNote() string {
+	return `当前工作目录下的代码包中未被其它代码包使用的包级输出标识符。
+方法和字段未被检查，因为它们可能通过接口和内嵌被隐式地使用。
+`
+}
+
+///////////////////////////////////////////////////////////////////
+// deprecated API uses page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_DeprecatedAPIUses() string {
+	return "已弃用API的使用"
+}
+
+func (*Chinese) Text_DeprecatedAPIUsesNote() string {
+	return `当前工作目录下的代码包中对（其它代码包中的）已弃用声明的使用。
+`
+}
+
+func (*Chinese) Text_DeprecatedAPIUsesWithDetailsLink(count int, detailsLink string) string {
+	if count == 0 {
+		return "已弃用API的使用（无）"
+	}
+	return fmt.Sprintf(`已弃用API的使用（<a href="%s">%d</a>）`, detailsLink, count)
+}
+
 ///////////////////////////////////////////////////////////////////
 // footer
 ///////////////////////////////////////////////////////////////////
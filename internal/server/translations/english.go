@@ -45,6 +45,10 @@ func (*English) Text_PreferredFontList() string { return `"Courier New", Courier
 
 func (*English) Text_BlankList() string { return "(none)" }
 
+func (*English) Text_Theme() string { return "Theme" }
+
+func (*English) Text_Language() string { return "Language" }
+
 ///////////////////////////////////////////////////////////////////
 // server
 ///////////////////////////////////////////////////////////////////
@@ -53,6 +57,10 @@ func (*English) Text_Server_Started() string {
 	return "Server started:"
 }
 
+func (*English) Text_Server_SourceChanged() string {
+	return "Source code changes are detected. Re-analyzing ..."
+}
+
 ///////////////////////////////////////////////////////////////////
 // analyzing
 ///////////////////////////////////////////////////////////////////
@@ -136,6 +144,10 @@ func (*English) Text_Analyzing_CacheSourceFiles(d time.Duration) string {
 	return fmt.Sprintf("Cached Source Files: %s", d)
 }
 
+func (*English) Text_Analyzing_CollectExamples(d time.Duration) string {
+	return fmt.Sprintf("Collected Examples: %s", d)
+}
+
 func (*English) Text_Analyzing_Done(d time.Duration, memoryUse string) string {
 	return fmt.Sprintf("Done. (Total time: %s, used memory: %s)", d, memoryUse)
 }
@@ -210,8 +222,12 @@ func (*English) Text_SortByItem(by string) string {
 		return "alphabet"
 	case "popularity":
 		return "popularity"
+	case "usage":
+		return "usage"
 	case "importedbys":
 		return "imported-by count"
+	case "modules":
+		return "module"
 	default:
 		panic("unknown sort-by: " + by)
 	}
@@ -230,6 +246,30 @@ func (*English) Text_FilterItem(fltr string) string {
 	}
 }
 
+///////////////////////////////////////////////////////////////////
+// search page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_Search() string { return "Search" }
+
+///////////////////////////////////////////////////////////////////
+// module page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_Module(modulePath string) string {
+	return fmt.Sprintf("Module: %s", modulePath)
+}
+
+func (*English) Text_ModulePath() string { return "Module Path" }
+
+func (*English) Text_Version() string { return "Version" }
+
+func (*English) Text_ReplacedBy() string { return "Replaced By" }
+
+func (*English) Text_Requires() string { return "Requires" }
+
+func (*English) Text_RequiredBy() string { return "Required By" }
+
 ///////////////////////////////////////////////////////////////////
 // package details page: type details
 ///////////////////////////////////////////////////////////////////
@@ -352,6 +392,44 @@ func (*English) Text_References(num int) string {
 	return fmt.Sprintf("References (%d+)", num)
 }
 
+func (*English) Text_Examples(num int) string {
+	if num == 1 {
+		return "One Example"
+	}
+	return fmt.Sprintf("Examples (%d)", num)
+}
+
+func (*English) Text_TypeSet() string {
+	return "Type Set"
+}
+
+func (*English) Text_InheritedFrom() string {
+	return "inherited from "
+}
+
+func (*English) Text_Deprecated() string {
+	return "Deprecated"
+}
+
+func (*English) Text_OnlyOnPlatforms(platforms string) string {
+	return "(only on " + platforms + ")"
+}
+
+func (*English) Text_DeclarationsOnOtherPlatforms(num int) string {
+	return fmt.Sprintf("Only on Other Platforms (%d)", num)
+}
+
+func (*English) Text_UsedByPackages(numPkgs, numUses int) string {
+	var pkgs, uses = "one package", "once"
+	if numPkgs > 1 {
+		pkgs = fmt.Sprintf("%d packages", numPkgs)
+	}
+	if numUses > 1 {
+		uses = fmt.Sprintf("%d times", numUses)
+	}
+	return "(used by " + pkgs + " / " + uses + ")"
+}
+
 ///////////////////////////////////////////////////////////////////
 // package dependencies page
 ///////////////////////////////////////////////////////////////////
@@ -368,6 +446,44 @@ func (*English) Text_Imports() string { return "Imports" }
 
 func (*English) Text_ImportedBy() string { return "Imported By" }
 
+func (*English) Text_UsedExportedIdentifiers() string { return "Exported Identifiers Used By Importers" }
+
+func (*English) Text_ImportViolations() string { return "Import Rule Violations" }
+
+func (*English) Text_ImportViolationsWithDetailsLink(count int, detailsLink string) string {
+	switch count {
+	case 0:
+		return "Import Rule Violations (none)"
+	case 1:
+		return fmt.Sprintf(`Import Rule Violations (<a href="%s">one</a>)`, detailsLink)
+	default:
+		return fmt.Sprintf(`Import Rule Violations (<a href="%s">%d</a>)`, detailsLink, count)
+	}
+}
+
+func (*English) Text_ForbiddenByImportRule(line int, rule string) string {
+	return fmt.Sprintf("(forbidden by the rule at line %d: %s)", line, rule)
+}
+
+func (*English) Text_DependencyGraph() string { return "Dependency Graph" }
+
+func (*English) Text_DependencyGraphOption(option string) string {
+	switch option {
+	case "depth":
+		return "depth: "
+	case "unlimited":
+		return "unlimited"
+	case "std":
+		return "standard packages: "
+	case "show":
+		return "show"
+	case "hide":
+		return "hide"
+	default:
+		panic("unknown dependency graph option: " + option)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // method implementation page
 ///////////////////////////////////////////////////////////////////
@@ -387,6 +503,37 @@ func (*English) Text_NumMethodsImplementingNothing(count int) string {
 	return fmt.Sprintf(" (%d other method%s implement%s nothing)", count, s1, s2)
 }
 
+///////////////////////////////////////////////////////////////////
+// type hierarchy page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_TypeHierarchy() string { return "Type Hierarchy" }
+
+func (*English) Text_EmbeddingTree(forInterface bool) string {
+	if forInterface {
+		return "Embedded Interfaces"
+	}
+	return "Embedded Fields"
+}
+
+///////////////////////////////////////////////////////////////////
+// function calls page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_FunctionCalls() string { return "Function Calls" }
+
+func (*English) Text_Callers(num int) string {
+	return fmt.Sprintf("Callers (%d)", num)
+}
+
+func (*English) Text_Callees(num int) string {
+	return fmt.Sprintf("Callees (%d)", num)
+}
+
+func (*English) Text_ThroughInterfaceMethod(method string) string {
+	return fmt.Sprintf("(through %s)", method)
+}
+
 ///////////////////////////////////////////////////////////////////
 // object reference page
 ///////////////////////////////////////////////////////////////////
@@ -413,6 +560,17 @@ func (*English) Text_ObjectUses(num int) string {
 	return fmt.Sprintf("%d uses", num)
 }
 
+func (*English) Text_DeprecatedUses(num int) string {
+	switch num {
+	case 0:
+		return "Deprecated, not used in other packages"
+	case 1:
+		return "Deprecated, used once in other packages"
+	default:
+		return fmt.Sprintf("Deprecated, used %d times in other packages", num)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // source code page
 ///////////////////////////////////////////////////////////////////
@@ -425,6 +583,14 @@ func (*English) Text_SourceFilePath() string { return "Source File" }
 
 func (*English) Text_GeneratedFrom() string { return "Generated From" }
 
+func (*English) Text_InterfaceMethodCallImplementations() string {
+	return "Implementations of Interface Method Calls"
+}
+
+func (*English) Text_SourceLine(line int) string {
+	return fmt.Sprintf("line %d", line)
+}
+
 ///////////////////////////////////////////////////////////////////
 // statistics
 ///////////////////////////////////////////////////////////////////
@@ -615,13 +781,58 @@ func (*English) Text_Othertatistics(values map[string]interface{}) string {
 	The average length of exported identifiers is %.2f.
 
 	<img src="%s"></image>
+
+	The exported identifiers (in the packages under the working
+	directory) not used by other packages are listed <a href="%s">here</a>.
 `,
 		values["averageIdentiferLength"],
 
 		values["exportedidentifiersByLengthsChartURL"],
+
+		values["unusedExportedIdentifiersURL"],
 	)
 }
 
+///////////////////////////////////////////////////////////////////
+// unused identifiers page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_UnusedExportedIdentifiers() string {
+	return "Unused Exported Identifiers"
+}
+
+func (*English) Text_UnusedExportedIdentifiersNote() string {
+	return `The exported package-level identifiers (in the packages under the working
+directory) which are not used by other packages. Methods and fields are not
+checked, for they might be used implicitly through interfaces and embeddings.
+`
+}
+
+///////////////////////////////////////////////////////////////////
+// deprecated API uses page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_DeprecatedAPIUses() string {
+	return "Uses of Deprecated APIs"
+}
+
+func (*English) Text_DeprecatedAPIUsesNote() string {
+	return `The uses of the deprecated declarations (in other packages) in the packages
+under the working directory.
+`
+}
+
+func (*English) Text_DeprecatedAPIUsesWithDetailsLink(count int, detailsLink string) string {
+	switch count {
+	case 0:
+		return "Uses of Deprecated APIs (none)"
+	case 1:
+		return fmt.Sprintf(`Uses of Deprecated APIs (<a href="%s">one</a>)`, detailsLink)
+	default:
+		return fmt.Sprintf(`Uses of Deprecated APIs (<a href="%s">%d</a>)`, detailsLink, count)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // footer
 ///////////////////////////////////////////////////////////////////
@@ -0,0 +1,53 @@
+package generics
+
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+type List[T any] struct {
+	items []T
+}
+
+func (l *List[T]) Push(v T) {
+	l.items = append(l.items, v)
+}
+
+func (l *List[T]) Len() int {
+	return len(l.items)
+}
+
+type Map[K comparable, V any] map[K]V
+
+func (m Map[K, V]) Get(k K) V {
+	return m[k]
+}
+
+type Lener interface {
+	Len() int
+}
+
+type Pusher[T any] interface {
+	Push(T)
+}
+
+type IntList = List[int]
+
+type Stack struct {
+	*List[string]
+}
+
+func Sum[T Number](vs ...T) T {
+	var s T
+	for _, v := range vs {
+		s += v
+	}
+	return s
+}
+
+func NewList[T any]() *List[T] {
+	return &List[T]{}
+}
+
+var Ints List[int]
+
+var Pairs Map[string, int]
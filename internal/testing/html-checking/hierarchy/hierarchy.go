@@ -0,0 +1,24 @@
+package hierarchy
+
+import "io"
+
+type ReadCloser interface {
+	io.Reader
+	io.Closer
+}
+
+type closer struct{}
+
+func (closer) Close() error { return nil }
+
+type Inner struct {
+	closer
+}
+
+type T struct {
+	Inner
+}
+
+func (*T) Read(p []byte) (int, error) { return 0, io.EOF }
+
+var _ ReadCloser = (*T)(nil)
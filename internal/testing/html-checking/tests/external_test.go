@@ -0,0 +1,11 @@
+package tests_test
+
+import "go101.org/golds/internal/testing/html-checking/tests"
+
+type ExternalSizer struct{}
+
+func (ExternalSizer) Size() int {
+	return 1
+}
+
+var _ tests.Sizer = ExternalSizer{}
@@ -0,0 +1,13 @@
+package tests
+
+type Sizer interface {
+	Size() int
+}
+
+type T struct {
+	n int
+}
+
+func (t T) N() int {
+	return t.n
+}
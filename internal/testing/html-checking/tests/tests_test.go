@@ -0,0 +1,25 @@
+package tests
+
+import "testing"
+
+// Size is declared in a test file for a non-test type.
+func (t *T) Size() int {
+	return t.n
+}
+
+func (t *T) check(tb testing.TB) {
+	if t.Size() != t.N() {
+		tb.Fatal("size mismatch")
+	}
+}
+
+// U's source type is a non-test type.
+type U T
+
+type fakeSizer struct{}
+
+func (fakeSizer) Size() int {
+	return 0
+}
+
+var _ Sizer = fakeSizer{}
@@ -0,0 +1,7 @@
+package user
+
+import "go101.org/golds/internal/testing/html-checking/tests"
+
+type W struct {
+	tests.T
+}